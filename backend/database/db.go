@@ -1,6 +1,9 @@
 package database
 
 import (
+	"math"
+	"strings"
+
 	"github.com/scoresystem/backend/config"
 	"github.com/scoresystem/backend/models"
 	"gorm.io/driver/sqlite"
@@ -16,9 +19,44 @@ func Connect() error {
 		return err
 	}
 
+	if err := migrateScoreValues(); err != nil {
+		return err
+	}
 	return autoMigrate()
 }
 
+// migrateScoreValues converts score values stored as REAL into the whole
+// thousandths models.Decimal keeps. The values and the column type change
+// together, so a failed migration is not applied twice.
+func migrateScoreValues() error {
+	for _, model := range []interface{}{&models.Score{}, &models.ScoreRevision{}} {
+		if !DB.Migrator().HasTable(model) {
+			continue
+		}
+		columns, err := DB.Migrator().ColumnTypes(model)
+		if err != nil {
+			return err
+		}
+		for _, column := range columns {
+			if column.Name() != "value" || !strings.EqualFold(column.DatabaseTypeName(), "real") {
+				continue
+			}
+			err := DB.Transaction(func(tx *gorm.DB) error {
+				err := tx.Model(model).Unscoped().Where("1 = 1").
+					UpdateColumn("value", gorm.Expr("CAST(ROUND(value * ?) AS INTEGER)", math.Pow10(models.DecimalPlaces))).Error
+				if err != nil {
+					return err
+				}
+				return tx.Migrator().AlterColumn(model, "Value")
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func autoMigrate() error {
 	return DB.AutoMigrate(
 		&models.User{},
@@ -27,5 +65,11 @@ func autoMigrate() error {
 		&models.Participant{},
 		&models.Game{},
 		&models.Score{},
+		&models.ScoreRevision{},
+		&models.Match{},
+		&models.MatchEntry{},
+		&models.Bracket{},
+		&models.Schedule{},
+		&models.Heat{},
 	)
 }
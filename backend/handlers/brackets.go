@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scoresystem/backend/database"
+	"github.com/scoresystem/backend/middleware"
+	"github.com/scoresystem/backend/models"
+	"github.com/scoresystem/backend/scoring"
+	"github.com/scoresystem/backend/tournament"
+	"github.com/scoresystem/backend/utils"
+	"github.com/scoresystem/backend/websocket"
+	"gorm.io/gorm"
+)
+
+type CreateBracketRequest struct {
+	Type     string `json:"type" binding:"required"`
+	Seeding  string `json:"seeding"`
+	GroupIDs []uint `json:"group_ids"`
+}
+
+type MatchRound struct {
+	Round   int            `json:"round"`
+	Matches []models.Match `json:"matches"`
+}
+
+type BracketTree struct {
+	models.Bracket
+	Winners    []MatchRound  `json:"winners"`
+	Losers     []MatchRound  `json:"losers,omitempty"`
+	GrandFinal *models.Match `json:"grand_final,omitempty"`
+	Reset      *models.Match `json:"grand_final_reset,omitempty"`
+}
+
+func GetGameBracket(c *gin.Context) {
+	slug := c.Param("slug")
+	gameID := c.Param("gameId")
+
+	var event models.Event
+	result := database.DB.Where("slug = ?", slug).First(&event)
+	if result.Error != nil {
+		utils.NotFound(c, "event not found")
+		return
+	}
+
+	var bracket models.Bracket
+	result = database.DB.Joins("JOIN games ON games.id = brackets.game_id").
+		Where("brackets.game_id = ? AND games.event_id = ?", gameID, event.ID).
+		First(&bracket)
+	if result.Error != nil {
+		utils.NotFound(c, "bracket not found")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, bracketTree(bracket, publicCutoff(c, event)))
+}
+
+func CreateBracket(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	gameID := c.Param("id")
+
+	var game models.Game
+	result := database.DB.Preload("Event").First(&game, gameID)
+	if result.Error != nil {
+		utils.NotFound(c, "game not found")
+		return
+	}
+
+	if game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	if game.ScoringMode != scoring.ModeMatch {
+		utils.BadRequest(c, "game is not scored by matches")
+		return
+	}
+
+	var req CreateBracketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "invalid request body")
+		return
+	}
+
+	if !tournament.ValidBracketType(req.Type) {
+		utils.BadRequest(c, "invalid bracket type")
+		return
+	}
+
+	seeding := tournament.SeedingLeaderboard
+	if req.Seeding != "" {
+		seeding = req.Seeding
+	}
+	if !tournament.ValidSeeding(seeding) {
+		utils.BadRequest(c, "invalid seeding")
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.Bracket{}).Where("game_id = ?", game.ID).Count(&existing)
+	if existing > 0 {
+		utils.BadRequest(c, "game already has a bracket")
+		return
+	}
+
+	seen := make(map[uint]bool)
+	for _, id := range req.GroupIDs {
+		if seen[id] {
+			utils.BadRequest(c, "each group can only be seeded once")
+			return
+		}
+		seen[id] = true
+	}
+	if len(req.GroupIDs) > 0 {
+		var count int64
+		database.DB.Model(&models.Group{}).Where("id IN ? AND event_id = ?", req.GroupIDs, game.EventID).Count(&count)
+		if int(count) != len(req.GroupIDs) {
+			utils.BadRequest(c, "invalid group")
+			return
+		}
+	}
+
+	var seeds []uint
+	if seeding == tournament.SeedingManual {
+		seeds = req.GroupIDs
+	} else {
+		for _, entry := range scoring.EventLeaderboard(game.Event, nil) {
+			if len(req.GroupIDs) == 0 || seen[entry.GroupID] {
+				seeds = append(seeds, entry.GroupID)
+			}
+		}
+	}
+
+	if len(seeds) < tournament.MinGroups(req.Type) {
+		utils.BadRequest(c, "not enough groups for this bracket")
+		return
+	}
+
+	bracket := models.Bracket{
+		GameID:    game.ID,
+		Type:      req.Type,
+		Seeding:   seeding,
+		Status:    "active",
+		CreatedBy: userID,
+	}
+
+	slots := tournament.Layout(req.Type, len(seeds))
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&bracket).Error; err != nil {
+			return err
+		}
+
+		matches := make([]models.Match, len(slots))
+		for i, slot := range slots {
+			matches[i] = models.Match{
+				GameID:    game.ID,
+				BracketID: &bracket.ID,
+				Stage:     slot.Stage,
+				Round:     slot.Round,
+				Position:  slot.Position,
+				Status:    "pending",
+				CreatedBy: userID,
+			}
+			for _, seed := range slot.Seeds {
+				if seed > 0 {
+					matches[i].Entries = append(matches[i].Entries, models.MatchEntry{GroupID: seeds[seed-1], Seed: seed})
+				}
+			}
+		}
+		if err := tx.Create(&matches).Error; err != nil {
+			return err
+		}
+
+		for i, slot := range slots {
+			updates := make(map[string]interface{})
+			if slot.WinnerNext >= 0 {
+				updates["winner_next_id"] = matches[slot.WinnerNext].ID
+			}
+			if slot.LoserNext >= 0 {
+				updates["loser_next_id"] = matches[slot.LoserNext].ID
+			}
+			if len(updates) == 0 {
+				continue
+			}
+			if err := tx.Model(&matches[i]).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		return tournament.Settle(tx, bracket.ID)
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to create bracket")
+		return
+	}
+
+	database.DB.First(&bracket, bracket.ID)
+
+	utils.SuccessResponse(c, 201, bracketTree(bracket, nil))
+}
+
+func DeleteBracket(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	bracketID := c.Param("id")
+
+	var bracket models.Bracket
+	result := database.DB.Preload("Game.Event").First(&bracket, bracketID)
+	if result.Error != nil {
+		utils.NotFound(c, "bracket not found")
+		return
+	}
+
+	if bracket.Game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	matchIDs := database.DB.Model(&models.Match{}).Select("id").Where("bracket_id = ?", bracket.ID)
+
+	var scores []models.Score
+	database.DB.Where("match_id IN (?)", matchIDs).Find(&scores)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		ids := tx.Model(&models.Match{}).Select("id").Where("bracket_id = ?", bracket.ID)
+		if err := tx.Where("match_id IN (?)", ids).Delete(&models.Score{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("match_id IN (?)", ids).Delete(&models.MatchEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("bracket_id = ?", bracket.ID).Delete(&models.Match{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&bracket).Error
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to delete bracket")
+		return
+	}
+
+	scoring.InvalidateLeaderboard(bracket.Game.EventID)
+	for _, score := range scores {
+		websocket.BroadcastScoreDelete(bracket.Game.EventID, score, scoring.GroupGameScore(bracket.Game, score.GroupID))
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{"message": "bracket deleted"})
+}
+
+// bracketTree groups a bracket's matches by stage and round. Each match
+// carries winner_next_id and loser_next_id so clients can draw the links.
+// With a cutoff the bracket is shown as it stood then.
+func bracketTree(bracket models.Bracket, cutoff *time.Time) BracketTree {
+	var matches []models.Match
+	database.DB.Where("bracket_id = ?", bracket.ID).
+		Preload("Entries", func(db *gorm.DB) *gorm.DB { return db.Order("seed, id") }).
+		Preload("Entries.Group").
+		Order("round, position").
+		Find(&matches)
+	if cutoff != nil {
+		matches = matchesAt(matches, *cutoff)
+		if bracket.Status == "completed" && bracket.UpdatedAt.After(*cutoff) {
+			bracket.Status = "active"
+			bracket.ChampionID = nil
+		}
+	}
+
+	tree := BracketTree{Bracket: bracket, Winners: []MatchRound{}}
+	for i := range matches {
+		m := matches[i]
+		switch m.Stage {
+		case tournament.StageWinners:
+			tree.Winners = appendToRound(tree.Winners, m)
+		case tournament.StageLosers:
+			tree.Losers = appendToRound(tree.Losers, m)
+		case tournament.StageGrandFinal:
+			if m.Round > 1 {
+				tree.Reset = &m
+			} else {
+				tree.GrandFinal = &m
+			}
+		}
+	}
+	return tree
+}
+
+func appendToRound(rounds []MatchRound, match models.Match) []MatchRound {
+	if len(rounds) == 0 || rounds[len(rounds)-1].Round != match.Round {
+		rounds = append(rounds, MatchRound{Round: match.Round})
+	}
+	last := &rounds[len(rounds)-1]
+	last.Matches = append(last.Matches, match)
+	return rounds
+}
@@ -1,23 +1,50 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/scoresystem/backend/database"
 	"github.com/scoresystem/backend/middleware"
 	"github.com/scoresystem/backend/models"
+	"github.com/scoresystem/backend/scoring"
 	"github.com/scoresystem/backend/utils"
+	"github.com/scoresystem/backend/websocket"
+	"gorm.io/gorm"
 )
 
 type CreateEventRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
+	Name           string            `json:"name" binding:"required"`
+	Description    string            `json:"description"`
+	Status         string            `json:"status"`
+	Tiebreakers    []string          `json:"tiebreakers"`
+	TiebreakGameID *uint             `json:"tiebreak_game_id"`
+	CategoryLabels map[string]string `json:"category_labels"`
+	NormalizeSize  bool              `json:"normalize_size"`
 }
 
 type UpdateEventRequest struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description"`
+	Status         string            `json:"status"`
+	Tiebreakers    []string          `json:"tiebreakers"`
+	TiebreakGameID nullableID        `json:"tiebreak_game_id"`
+	CategoryLabels map[string]string `json:"category_labels"`
+	NormalizeSize  *bool             `json:"normalize_size"`
+}
+
+// nullableID tells a JSON null, which clears a reference, apart from a field
+// that was left out.
+type nullableID struct {
+	Set bool
+	ID  *uint
+}
+
+func (n *nullableID) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	return json.Unmarshal(data, &n.ID)
 }
 
 func ListPublicEvents(c *gin.Context) {
@@ -63,15 +90,43 @@ func CreateEvent(c *gin.Context) {
 		status = req.Status
 	}
 
+	if err := scoring.ValidateTiebreakers(req.Tiebreakers, req.TiebreakGameID); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := scoring.ValidateCategoryLabels(req.CategoryLabels); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
 	event := models.Event{
-		Name:        req.Name,
-		Slug:        utils.GenerateSlug(req.Name),
-		Description: req.Description,
-		Status:      status,
-		CreatedBy:   userID,
+		Name:           req.Name,
+		Slug:           utils.GenerateSlug(req.Name),
+		Description:    req.Description,
+		Status:         status,
+		CreatedBy:      userID,
+		Tiebreakers:    req.Tiebreakers,
+		TiebreakGameID: req.TiebreakGameID,
+		CategoryLabels: req.CategoryLabels,
+		NormalizeSize:  req.NormalizeSize,
 	}
 
-	if result := database.DB.Create(&event); result.Error != nil {
+	errInvalidGame := errors.New("invalid tiebreak game")
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		if !isEventGame(tx, event.ID, event.TiebreakGameID) {
+			return errInvalidGame
+		}
+		return nil
+	})
+	if err == errInvalidGame {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
 		utils.InternalError(c, "failed to create event")
 		return
 	}
@@ -106,22 +161,219 @@ func UpdateEvent(c *gin.Context) {
 	if req.Status != "" {
 		updates["status"] = req.Status
 	}
+	tiebreakers, tiebreakGameID := []string(event.Tiebreakers), event.TiebreakGameID
+	if req.Tiebreakers != nil {
+		tiebreakers = req.Tiebreakers
+		updates["tiebreakers"] = models.StringList(req.Tiebreakers)
+	}
+	if req.TiebreakGameID.Set {
+		if !isEventGame(database.DB, event.ID, req.TiebreakGameID.ID) {
+			utils.BadRequest(c, "invalid tiebreak game")
+			return
+		}
+		tiebreakGameID = req.TiebreakGameID.ID
+		updates["tiebreak_game_id"] = req.TiebreakGameID.ID
+	}
+	if err := scoring.ValidateTiebreakers(tiebreakers, tiebreakGameID); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if req.CategoryLabels != nil {
+		if err := scoring.ValidateCategoryLabels(req.CategoryLabels); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		updates["category_labels"] = models.StringMap(req.CategoryLabels)
+	}
+	if req.NormalizeSize != nil {
+		updates["normalize_size"] = *req.NormalizeSize
+	}
 
 	database.DB.Model(&event).Updates(updates)
 	database.DB.First(&event, event.ID)
 
+	scoring.InvalidateLeaderboard(event.ID)
+	websocket.BroadcastEventChange(websocket.MessageTypeEventUpdate, event)
+
 	utils.SuccessResponse(c, 200, event)
 }
 
+// isEventGame reports whether a game belongs to the event. A missing game is
+// allowed, since references to games are optional.
+func isEventGame(db *gorm.DB, eventID uint, gameID *uint) bool {
+	if gameID == nil {
+		return true
+	}
+	var count int64
+	db.Model(&models.Game{}).Where("id = ? AND event_id = ?", *gameID, eventID).Count(&count)
+	return count > 0
+}
+
 func DeleteEvent(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	eventID := c.Param("id")
 
-	result := database.DB.Where("id = ? AND created_by = ?", eventID, userID).Delete(&models.Event{})
-	if result.RowsAffected == 0 {
+	var event models.Event
+	result := database.DB.Where("id = ? AND created_by = ?", eventID, userID).First(&event)
+	if result.Error != nil {
 		utils.NotFound(c, "event not found")
 		return
 	}
 
+	database.DB.Delete(&event)
+
+	websocket.BroadcastEventChange(websocket.MessageTypeEventDelete, event)
+
 	utils.SuccessResponse(c, 200, gin.H{"message": "event deleted"})
 }
+
+func FreezeEvent(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	eventID := c.Param("id")
+
+	var event models.Event
+	result := database.DB.Where("id = ? AND created_by = ?", eventID, userID).First(&event)
+	if result.Error != nil {
+		utils.NotFound(c, "event not found")
+		return
+	}
+
+	if event.FrozenAt != nil {
+		utils.BadRequest(c, "event is already frozen")
+		return
+	}
+
+	frozenAt := time.Now()
+	database.DB.Model(&event).Update("frozen_at", frozenAt)
+	event.FrozenAt = &frozenAt
+
+	websocket.BroadcastFreeze(event.ID, frozenAt)
+
+	utils.SuccessResponse(c, 200, event)
+}
+
+// RevealEvent lifts a freeze and pushes the final standings to every viewer.
+func RevealEvent(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	eventID := c.Param("id")
+
+	var event models.Event
+	result := database.DB.Where("id = ? AND created_by = ?", eventID, userID).First(&event)
+	if result.Error != nil {
+		utils.NotFound(c, "event not found")
+		return
+	}
+
+	if event.FrozenAt == nil {
+		utils.BadRequest(c, "event is not frozen")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, finishReveal(event))
+}
+
+// StartStagedReveal begins revealing the standings one position at a time,
+// from last place up. The public leaderboard stays frozen meanwhile and only
+// lists the positions revealed so far.
+func StartStagedReveal(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	eventID := c.Param("id")
+
+	var event models.Event
+	result := database.DB.Where("id = ? AND created_by = ?", eventID, userID).First(&event)
+	if result.Error != nil {
+		utils.NotFound(c, "event not found")
+		return
+	}
+
+	if event.Revealing {
+		utils.Conflict(c, "a reveal is already in progress")
+		return
+	}
+
+	// The order is fixed now so that scores coming in during the reveal
+	// cannot show a group twice or skip one.
+	leaderboard := scoring.EventLeaderboard(event, nil)
+	order := make(models.IntList, len(leaderboard))
+	for i, entry := range leaderboard {
+		order[i] = int(entry.GroupID)
+	}
+
+	updates := map[string]interface{}{
+		"revealing":    true,
+		"reveal_step":  0,
+		"reveal_order": order,
+	}
+	if event.FrozenAt == nil {
+		updates["frozen_at"] = time.Now()
+	}
+	database.DB.Model(&event).Updates(updates)
+
+	websocket.BroadcastRevealStart(event.ID, len(order))
+
+	database.DB.First(&event, event.ID)
+
+	utils.SuccessResponse(c, 200, event)
+}
+
+// RevealNext shows the next position of a staged reveal, in the order the
+// standings were in when it started. Once the winner is shown the freeze is
+// lifted and the full standings are broadcast.
+func RevealNext(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	eventID := c.Param("id")
+
+	var event models.Event
+	result := database.DB.Where("id = ? AND created_by = ?", eventID, userID).First(&event)
+	if result.Error != nil {
+		utils.NotFound(c, "event not found")
+		return
+	}
+
+	if !event.Revealing {
+		utils.BadRequest(c, "no reveal in progress")
+		return
+	}
+
+	order := event.RevealOrder
+	if event.RevealStep >= len(order) {
+		utils.SuccessResponse(c, 200, finishReveal(event))
+		return
+	}
+
+	step := event.RevealStep + 1
+	groupID := uint(order[len(order)-step])
+	entry := scoring.LeaderboardEntry{GroupID: groupID}
+	for _, e := range scoring.EventLeaderboard(event, nil) {
+		if e.GroupID == groupID {
+			entry = e
+			break
+		}
+	}
+	remaining := len(order) - step
+	database.DB.Model(&event).Update("reveal_step", step)
+
+	websocket.BroadcastRevealNext(event.ID, entry, remaining)
+	if remaining == 0 {
+		event.RevealStep = step
+		finishReveal(event)
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{"entry": entry, "remaining": remaining})
+}
+
+func finishReveal(event models.Event) []scoring.LeaderboardEntry {
+	database.DB.Model(&event).Updates(map[string]interface{}{
+		"frozen_at":    nil,
+		"revealing":    false,
+		"reveal_step":  0,
+		"reveal_order": models.IntList{},
+	})
+	event.FrozenAt = nil
+	event.Revealing = false
+	event.RevealOrder = nil
+
+	leaderboard := scoring.EventLeaderboard(event, nil)
+	websocket.BroadcastReveal(event.ID, leaderboard)
+	return leaderboard
+}
@@ -5,15 +5,38 @@ import (
 	"github.com/scoresystem/backend/database"
 	"github.com/scoresystem/backend/middleware"
 	"github.com/scoresystem/backend/models"
+	"github.com/scoresystem/backend/scoring"
 	"github.com/scoresystem/backend/utils"
+	"github.com/scoresystem/backend/websocket"
+	"gorm.io/gorm"
 )
 
 type CreateGameRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	ScoringMode string `json:"scoring_mode"`
-	Status      string `json:"status"`
-	SortOrder   int    `json:"sort_order"`
+	Name             string         `json:"name" binding:"required"`
+	Description      string         `json:"description"`
+	ScoringMode      string         `json:"scoring_mode"`
+	PointsTable      models.IntList `json:"points_table"`
+	TieMode          string         `json:"tie_mode"`
+	Weight           *float64       `json:"weight"`
+	MetricType       string         `json:"metric_type"`
+	SortDirection    string         `json:"sort_direction"`
+	Conversion       *string        `json:"conversion"`
+	MaxPoints        *int           `json:"max_points"`
+	Decimals         *int           `json:"decimals"`
+	Formula          *string        `json:"formula"`
+	MinValue         *float64       `json:"min_value"`
+	MaxValue         *float64       `json:"max_value"`
+	MaxTotal         *float64       `json:"max_total"`
+	ClearLimits      []string       `json:"clear_limits"`
+	DisallowNegative *bool          `json:"disallow_negative"`
+	Aggregation      string         `json:"aggregation"`
+	JudgeIDs         models.IntList `json:"judge_ids"`
+	WinPoints        *float64       `json:"win_points"`
+	DrawPoints       *float64       `json:"draw_points"`
+	LossPoints       *float64       `json:"loss_points"`
+	HeatCounting     *string        `json:"heat_counting"`
+	Status           string         `json:"status"`
+	SortOrder        int            `json:"sort_order"`
 }
 
 func ListEventGames(c *gin.Context) {
@@ -49,9 +72,81 @@ func CreateGame(c *gin.Context) {
 		return
 	}
 
-	scoringMode := "incremental"
-	if req.ScoringMode == "absolute" {
-		scoringMode = "absolute"
+	scoringMode := scoring.ModeIncremental
+	if req.ScoringMode != "" {
+		if !scoring.ValidMode(req.ScoringMode) {
+			utils.BadRequest(c, "invalid scoring mode")
+			return
+		}
+		scoringMode = req.ScoringMode
+	}
+
+	tieMode := scoring.TieAverage
+	if req.TieMode != "" {
+		tieMode = req.TieMode
+	}
+
+	weight := 1.0
+	if req.Weight != nil {
+		weight = *req.Weight
+	}
+
+	metricType := scoring.MetricPoints
+	if req.MetricType != "" {
+		metricType = req.MetricType
+	}
+
+	sortDirection := scoring.SortDesc
+	if req.SortDirection != "" {
+		sortDirection = req.SortDirection
+	} else if metricType == scoring.MetricTime {
+		sortDirection = scoring.SortAsc
+	}
+
+	conversion := ""
+	if req.Conversion != nil {
+		conversion = *req.Conversion
+	}
+
+	maxPoints := 0
+	if req.MaxPoints != nil {
+		maxPoints = *req.MaxPoints
+	}
+
+	decimals := 0
+	if req.Decimals != nil {
+		decimals = *req.Decimals
+	}
+
+	formula := ""
+	if req.Formula != nil {
+		formula = *req.Formula
+	}
+
+	aggregation := scoring.AggregateMean
+	if req.Aggregation != "" {
+		aggregation = req.Aggregation
+	}
+
+	winPoints, drawPoints, lossPoints := 3.0, 1.0, 0.0
+	if req.WinPoints != nil {
+		winPoints = *req.WinPoints
+	}
+	if req.DrawPoints != nil {
+		drawPoints = *req.DrawPoints
+	}
+	if req.LossPoints != nil {
+		lossPoints = *req.LossPoints
+	}
+
+	heatCounting := ""
+	if req.HeatCounting != nil {
+		heatCounting = *req.HeatCounting
+	}
+
+	if !validJudges(req.JudgeIDs) {
+		utils.BadRequest(c, "invalid judge")
+		return
 	}
 
 	status := "pending"
@@ -60,19 +155,47 @@ func CreateGame(c *gin.Context) {
 	}
 
 	game := models.Game{
-		EventID:     event.ID,
-		Name:        req.Name,
-		Description: req.Description,
-		ScoringMode: scoringMode,
-		Status:      status,
-		SortOrder:   req.SortOrder,
+		EventID:          event.ID,
+		Name:             req.Name,
+		Description:      req.Description,
+		ScoringMode:      scoringMode,
+		PointsTable:      req.PointsTable,
+		TieMode:          tieMode,
+		Weight:           weight,
+		MetricType:       metricType,
+		SortDirection:    sortDirection,
+		Conversion:       conversion,
+		MaxPoints:        maxPoints,
+		Decimals:         decimals,
+		Formula:          formula,
+		MinValue:         req.MinValue,
+		MaxValue:         req.MaxValue,
+		MaxTotal:         req.MaxTotal,
+		DisallowNegative: req.DisallowNegative != nil && *req.DisallowNegative,
+		Aggregation:      aggregation,
+		JudgeIDs:         req.JudgeIDs,
+		WinPoints:        winPoints,
+		DrawPoints:       drawPoints,
+		LossPoints:       lossPoints,
+		HeatCounting:     heatCounting,
+		Status:           status,
+		SortOrder:        req.SortOrder,
+	}
+
+	if err := scoring.ValidateGame(game); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
 	}
+	game.FormulaInputs = formulaInputs(game.Formula)
 
 	if result := database.DB.Create(&game); result.Error != nil {
 		utils.InternalError(c, "failed to create game")
 		return
 	}
 
+	scoring.InvalidateLeaderboard(game.EventID)
+	websocket.BroadcastGameChange(websocket.MessageTypeGameCreate, game)
+
 	utils.SuccessResponse(c, 201, game)
 }
 
@@ -107,13 +230,144 @@ func UpdateGame(c *gin.Context) {
 	}
 	if req.ScoringMode != "" {
 		updates["scoring_mode"] = req.ScoringMode
+		game.ScoringMode = req.ScoringMode
+	}
+	if req.PointsTable != nil {
+		updates["points_table"] = req.PointsTable
+		game.PointsTable = req.PointsTable
+	}
+	if req.TieMode != "" {
+		updates["tie_mode"] = req.TieMode
+		game.TieMode = req.TieMode
+	}
+	if req.Weight != nil {
+		updates["weight"] = *req.Weight
+		game.Weight = *req.Weight
+	}
+	if req.MetricType != "" {
+		updates["metric_type"] = req.MetricType
+		game.MetricType = req.MetricType
+	}
+	if req.SortDirection != "" {
+		updates["sort_direction"] = req.SortDirection
+		game.SortDirection = req.SortDirection
+	}
+	if req.Conversion != nil {
+		updates["conversion"] = *req.Conversion
+		game.Conversion = *req.Conversion
+	}
+	if req.MaxPoints != nil {
+		updates["max_points"] = *req.MaxPoints
+		game.MaxPoints = *req.MaxPoints
+	}
+	if req.Decimals != nil {
+		updates["decimals"] = *req.Decimals
+		game.Decimals = *req.Decimals
+	}
+	if req.Formula != nil {
+		updates["formula"] = *req.Formula
+		updates["formula_inputs"] = formulaInputs(*req.Formula)
+		game.Formula = *req.Formula
+	}
+	if req.MinValue != nil {
+		updates["min_value"] = *req.MinValue
+		game.MinValue = req.MinValue
+	}
+	if req.MaxValue != nil {
+		updates["max_value"] = *req.MaxValue
+		game.MaxValue = req.MaxValue
+	}
+	if req.MaxTotal != nil {
+		updates["max_total"] = *req.MaxTotal
+		game.MaxTotal = req.MaxTotal
+	}
+	for _, limit := range req.ClearLimits {
+		switch limit {
+		case "min_value":
+			game.MinValue = nil
+		case "max_value":
+			game.MaxValue = nil
+		case "max_total":
+			game.MaxTotal = nil
+		default:
+			utils.BadRequest(c, "invalid limit: "+limit)
+			return
+		}
+		updates[limit] = nil
+	}
+	if req.DisallowNegative != nil {
+		updates["disallow_negative"] = *req.DisallowNegative
+		game.DisallowNegative = *req.DisallowNegative
+	}
+	if req.Aggregation != "" {
+		updates["aggregation"] = req.Aggregation
+		game.Aggregation = req.Aggregation
+	}
+	if req.WinPoints != nil {
+		updates["win_points"] = *req.WinPoints
+		game.WinPoints = *req.WinPoints
+	}
+	if req.DrawPoints != nil {
+		updates["draw_points"] = *req.DrawPoints
+		game.DrawPoints = *req.DrawPoints
+	}
+	if req.LossPoints != nil {
+		updates["loss_points"] = *req.LossPoints
+		game.LossPoints = *req.LossPoints
+	}
+	if req.HeatCounting != nil {
+		updates["heat_counting"] = *req.HeatCounting
+		game.HeatCounting = *req.HeatCounting
+	}
+	if req.JudgeIDs != nil {
+		if !validJudges(req.JudgeIDs) {
+			utils.BadRequest(c, "invalid judge")
+			return
+		}
+		updates["judge_ids"] = req.JudgeIDs
 	}
 	if req.Status != "" {
 		updates["status"] = req.Status
 	}
 	updates["sort_order"] = req.SortOrder
 
-	database.DB.Model(&game).Updates(updates)
+	if err := scoring.ValidateGame(game); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	var recomputed []models.Score
+	if req.Formula != nil || req.Decimals != nil {
+		var err error
+		recomputed, err = recomputeFormulaScores(game)
+		if err != nil {
+			utils.BadRequest(c, "formula cannot be applied to existing scores: "+err.Error())
+			return
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&game).Updates(updates).Error; err != nil {
+			return err
+		}
+		for _, score := range recomputed {
+			if err := reviseScores(tx, userID, score.ID); err != nil {
+				return err
+			}
+			if err := tx.Model(&score).Update("value", score.Value).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to update game")
+		return
+	}
+
+	scoring.InvalidateLeaderboard(game.EventID)
+	websocket.BroadcastGameChange(websocket.MessageTypeGameUpdate, game)
+
 	database.DB.First(&game, game.ID)
 
 	utils.SuccessResponse(c, 200, game)
@@ -135,7 +389,67 @@ func DeleteGame(c *gin.Context) {
 		return
 	}
 
+	if id := game.Event.TiebreakGameID; id != nil && *id == game.ID {
+		utils.Conflict(c, "game is the event's tiebreak game; choose another one first")
+		return
+	}
+
 	database.DB.Delete(&game)
 
+	scoring.InvalidateLeaderboard(game.EventID)
+	websocket.BroadcastGameChange(websocket.MessageTypeGameDelete, game)
+
 	utils.SuccessResponse(c, 200, gin.H{"message": "game deleted"})
 }
+
+func validJudges(judgeIDs models.IntList) bool {
+	if len(judgeIDs) == 0 {
+		return true
+	}
+	var count int64
+	database.DB.Model(&models.User{}).Where("id IN ?", []int(judgeIDs)).Count(&count)
+	return int(count) == len(judgeIDs)
+}
+
+func isJudge(game models.Game, userID uint) bool {
+	if game.ScoringMode != scoring.ModeJudged {
+		return false
+	}
+	for _, id := range game.JudgeIDs {
+		if uint(id) == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func formulaInputs(formula string) models.StringList {
+	if formula == "" {
+		return models.StringList{}
+	}
+	f, err := scoring.CompileFormula(formula)
+	if err != nil {
+		return models.StringList{}
+	}
+	return f.Inputs()
+}
+
+// recomputeFormulaScores re-evaluates the stored value of every score in a
+// formula game that was recorded with raw inputs.
+func recomputeFormulaScores(game models.Game) ([]models.Score, error) {
+	if game.Formula == "" {
+		return nil, nil
+	}
+
+	var scores []models.Score
+	database.DB.Where("game_id = ? AND category = ? AND inputs IS NOT NULL", game.ID, scoring.CategoryBase).Find(&scores)
+
+	for i := range scores {
+		value, err := scoring.FormulaValue(game, scores[i].Inputs)
+		if err != nil {
+			return nil, err
+		}
+		scores[i].Value = models.NewDecimal(value)
+	}
+	return scores, nil
+}
@@ -1,23 +1,34 @@
 package handlers
 
 import (
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/scoresystem/backend/database"
 	"github.com/scoresystem/backend/middleware"
 	"github.com/scoresystem/backend/models"
+	"github.com/scoresystem/backend/scoring"
 	"github.com/scoresystem/backend/utils"
+	"github.com/scoresystem/backend/websocket"
 )
 
 type CreateGroupRequest struct {
-	Name      string `json:"name" binding:"required"`
-	Color     string `json:"color"`
-	SortOrder int    `json:"sort_order"`
+	Name      string   `json:"name" binding:"required"`
+	Color     string   `json:"color"`
+	Handicap  *float64 `json:"handicap"`
+	Division  *string  `json:"division"`
+	SortOrder int      `json:"sort_order"`
 }
 
 type CreateParticipantRequest struct {
 	Name string `json:"name" binding:"required"`
 }
 
+type UpdateParticipantRequest struct {
+	Name    string `json:"name"`
+	GroupID *uint  `json:"group_id"`
+}
+
 func ListEventGroups(c *gin.Context) {
 	slug := c.Param("slug")
 
@@ -57,12 +68,21 @@ func CreateGroup(c *gin.Context) {
 		Color:     req.Color,
 		SortOrder: req.SortOrder,
 	}
+	if req.Handicap != nil {
+		group.Handicap = *req.Handicap
+	}
+	if req.Division != nil {
+		group.Division = strings.TrimSpace(*req.Division)
+	}
 
 	if result := database.DB.Create(&group); result.Error != nil {
 		utils.InternalError(c, "failed to create group")
 		return
 	}
 
+	scoring.InvalidateLeaderboard(event.ID)
+	websocket.BroadcastGroupChange(websocket.MessageTypeGroupCreate, group)
+
 	utils.SuccessResponse(c, 201, group)
 }
 
@@ -95,11 +115,20 @@ func UpdateGroup(c *gin.Context) {
 	if req.Color != "" {
 		updates["color"] = req.Color
 	}
+	if req.Handicap != nil {
+		updates["handicap"] = *req.Handicap
+	}
+	if req.Division != nil {
+		updates["division"] = strings.TrimSpace(*req.Division)
+	}
 	updates["sort_order"] = req.SortOrder
 
 	database.DB.Model(&group).Updates(updates)
 	database.DB.First(&group, group.ID)
 
+	scoring.InvalidateLeaderboard(group.EventID)
+	websocket.BroadcastGroupChange(websocket.MessageTypeGroupUpdate, group)
+
 	utils.SuccessResponse(c, 200, group)
 }
 
@@ -121,6 +150,9 @@ func DeleteGroup(c *gin.Context) {
 
 	database.DB.Delete(&group)
 
+	scoring.InvalidateLeaderboard(group.EventID)
+	websocket.BroadcastGroupChange(websocket.MessageTypeGroupDelete, group)
+
 	utils.SuccessResponse(c, 200, gin.H{"message": "group deleted"})
 }
 
@@ -156,9 +188,59 @@ func CreateParticipant(c *gin.Context) {
 		return
 	}
 
+	scoring.InvalidateLeaderboard(group.EventID)
+	websocket.BroadcastParticipantChange(websocket.MessageTypeParticipantCreate, group.EventID, participant)
+
 	utils.SuccessResponse(c, 201, participant)
 }
 
+func UpdateParticipant(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	participantID := c.Param("id")
+
+	var participant models.Participant
+	result := database.DB.Preload("Group.Event").First(&participant, participantID)
+	if result.Error != nil {
+		utils.NotFound(c, "participant not found")
+		return
+	}
+
+	if participant.Group.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	var req UpdateParticipantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "invalid request body")
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.GroupID != nil {
+		var group models.Group
+		if database.DB.Where("id = ? AND event_id = ?", *req.GroupID, participant.Group.EventID).First(&group).Error != nil {
+			utils.BadRequest(c, "invalid group")
+			return
+		}
+		updates["group_id"] = group.ID
+	}
+
+	// The preloaded group would otherwise be saved back over a new group_id.
+	eventID := participant.Group.EventID
+	database.DB.Model(&models.Participant{}).Where("id = ?", participant.ID).Updates(updates)
+	participant = models.Participant{}
+	database.DB.First(&participant, participantID)
+
+	scoring.InvalidateLeaderboard(eventID)
+	websocket.BroadcastParticipantChange(websocket.MessageTypeParticipantUpdate, eventID, participant)
+
+	utils.SuccessResponse(c, 200, participant)
+}
+
 func DeleteParticipant(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	participantID := c.Param("id")
@@ -177,5 +259,8 @@ func DeleteParticipant(c *gin.Context) {
 
 	database.DB.Delete(&participant)
 
+	scoring.InvalidateLeaderboard(participant.Group.EventID)
+	websocket.BroadcastParticipantChange(websocket.MessageTypeParticipantDelete, participant.Group.EventID, participant)
+
 	utils.SuccessResponse(c, 200, gin.H{"message": "participant deleted"})
 }
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/scoresystem/backend/database"
+	"github.com/scoresystem/backend/middleware"
+	"github.com/scoresystem/backend/models"
+	"github.com/scoresystem/backend/scoring"
+	"github.com/scoresystem/backend/utils"
+	"github.com/scoresystem/backend/websocket"
+	"gorm.io/gorm"
+)
+
+type CreateHeatRequest struct {
+	Name      string         `json:"name" binding:"required"`
+	GroupIDs  models.IntList `json:"group_ids"`
+	Final     *bool          `json:"final"`
+	Status    string         `json:"status"`
+	SortOrder int            `json:"sort_order"`
+}
+
+func ListGameHeats(c *gin.Context) {
+	slug := c.Param("slug")
+	gameID := c.Param("gameId")
+
+	var event models.Event
+	result := database.DB.Where("slug = ?", slug).First(&event)
+	if result.Error != nil {
+		utils.NotFound(c, "event not found")
+		return
+	}
+
+	var game models.Game
+	result = database.DB.Where("id = ? AND event_id = ?", gameID, event.ID).First(&game)
+	if result.Error != nil {
+		utils.NotFound(c, "game not found")
+		return
+	}
+
+	var heats []models.Heat
+	database.DB.Where("game_id = ?", game.ID).Order("sort_order, id").Find(&heats)
+
+	utils.SuccessResponse(c, 200, heats)
+}
+
+func CreateHeat(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	gameID := c.Param("id")
+
+	var game models.Game
+	result := database.DB.Preload("Event").First(&game, gameID)
+	if result.Error != nil {
+		utils.NotFound(c, "game not found")
+		return
+	}
+
+	if game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	if game.HeatCounting == "" {
+		utils.BadRequest(c, "game is not run in heats")
+		return
+	}
+
+	var req CreateHeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "invalid request body")
+		return
+	}
+
+	if !validHeatGroups(game, req.GroupIDs) {
+		utils.BadRequest(c, "invalid group")
+		return
+	}
+
+	status := "pending"
+	if validHeatStatus(req.Status) {
+		status = req.Status
+	}
+
+	heat := models.Heat{
+		GameID:    game.ID,
+		Name:      req.Name,
+		GroupIDs:  req.GroupIDs,
+		Status:    status,
+		SortOrder: req.SortOrder,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&heat).Error; err != nil {
+			return err
+		}
+		if req.Final != nil && *req.Final {
+			return tx.Model(&game).Update("final_heat_id", heat.ID).Error
+		}
+		return nil
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to create heat")
+		return
+	}
+
+	scoring.InvalidateLeaderboard(game.EventID)
+
+	utils.SuccessResponse(c, 201, heat)
+}
+
+func UpdateHeat(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	heatID := c.Param("id")
+
+	var heat models.Heat
+	result := database.DB.Preload("Game.Event").First(&heat, heatID)
+	if result.Error != nil {
+		utils.NotFound(c, "heat not found")
+		return
+	}
+
+	if heat.Game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	var req CreateHeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "invalid request body")
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.GroupIDs != nil {
+		if !validHeatGroups(heat.Game, req.GroupIDs) {
+			utils.BadRequest(c, "invalid group")
+			return
+		}
+		updates["group_ids"] = req.GroupIDs
+	}
+	if req.Status != "" {
+		if !validHeatStatus(req.Status) {
+			utils.BadRequest(c, "invalid status")
+			return
+		}
+		updates["status"] = req.Status
+	}
+	updates["sort_order"] = req.SortOrder
+
+	if req.Final != nil && !*req.Final && isCountedFinal(heat) {
+		utils.BadRequest(c, "game counts only the final heat; change its heat counting first")
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&heat).Updates(updates).Error; err != nil {
+			return err
+		}
+		if req.Final == nil {
+			return nil
+		}
+		if *req.Final {
+			return tx.Model(&heat.Game).Update("final_heat_id", heat.ID).Error
+		}
+		if heat.Game.FinalHeatID != nil && *heat.Game.FinalHeatID == heat.ID {
+			return tx.Model(&heat.Game).Update("final_heat_id", nil).Error
+		}
+		return nil
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to update heat")
+		return
+	}
+
+	scoring.InvalidateLeaderboard(heat.Game.EventID)
+
+	database.DB.First(&heat, heat.ID)
+
+	utils.SuccessResponse(c, 200, heat)
+}
+
+func DeleteHeat(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	heatID := c.Param("id")
+
+	var heat models.Heat
+	result := database.DB.Preload("Game.Event").First(&heat, heatID)
+	if result.Error != nil {
+		utils.NotFound(c, "heat not found")
+		return
+	}
+
+	if heat.Game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	if isCountedFinal(heat) {
+		utils.BadRequest(c, "game counts only the final heat; change its heat counting first")
+		return
+	}
+
+	var scores []models.Score
+	database.DB.Where("heat_id = ?", heat.ID).Find(&scores)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("heat_id = ?", heat.ID).Delete(&models.Score{}).Error; err != nil {
+			return err
+		}
+		if heat.Game.FinalHeatID != nil && *heat.Game.FinalHeatID == heat.ID {
+			if err := tx.Model(&heat.Game).Update("final_heat_id", nil).Error; err != nil {
+				return err
+			}
+			heat.Game.FinalHeatID = nil
+		}
+		return tx.Delete(&heat).Error
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to delete heat")
+		return
+	}
+
+	scoring.InvalidateLeaderboard(heat.Game.EventID)
+
+	for _, score := range scores {
+		websocket.BroadcastScoreDelete(heat.Game.EventID, score, scoring.GroupGameScore(heat.Game, score.GroupID))
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{"message": "heat deleted"})
+}
+
+// isCountedFinal reports whether the heat is the only one its game counts.
+func isCountedFinal(heat models.Heat) bool {
+	game := heat.Game
+	return game.HeatCounting == scoring.HeatFinal && game.FinalHeatID != nil && *game.FinalHeatID == heat.ID
+}
+
+func validHeatStatus(status string) bool {
+	return status == "pending" || status == "active" || status == "completed"
+}
+
+func validHeatGroups(game models.Game, groupIDs models.IntList) bool {
+	if len(groupIDs) == 0 {
+		return true
+	}
+	seen := make(map[int]bool)
+	for _, id := range groupIDs {
+		if seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	var count int64
+	database.DB.Model(&models.Group{}).Where("id IN ? AND event_id = ?", []int(groupIDs), game.EventID).Count(&count)
+	return int(count) == len(groupIDs)
+}
@@ -1,27 +1,137 @@
 package handlers
 
 import (
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/scoresystem/backend/database"
+	"github.com/scoresystem/backend/middleware"
 	"github.com/scoresystem/backend/models"
+	"github.com/scoresystem/backend/scoring"
 	"github.com/scoresystem/backend/utils"
 )
 
-type LeaderboardEntry struct {
-	GroupID      uint        `json:"group_id"`
-	GroupName    string      `json:"group_name"`
-	GroupColor   string      `json:"group_color"`
-	TotalScore   int         `json:"total_score"`
-	ScoresByGame []GameScore `json:"scores_by_game"`
+func GetLeaderboard(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var event models.Event
+	result := database.DB.Where("slug = ?", slug).First(&event)
+	if result.Error != nil {
+		utils.NotFound(c, "event not found")
+		return
+	}
+
+	scope, ok := leaderboardScope(c, event)
+	if !ok {
+		return
+	}
+
+	respondLeaderboard(c, event, scope)
 }
 
-type GameScore struct {
-	GameID   uint   `json:"game_id"`
-	GameName string `json:"game_name"`
-	Score    int    `json:"score"`
+func GetGameLeaderboard(c *gin.Context) {
+	slug := c.Param("slug")
+	gameID := c.Param("gameId")
+
+	var event models.Event
+	result := database.DB.Where("slug = ?", slug).First(&event)
+	if result.Error != nil {
+		utils.NotFound(c, "event not found")
+		return
+	}
+
+	var game models.Game
+	result = database.DB.Where("id = ? AND event_id = ?", gameID, event.ID).First(&game)
+	if result.Error != nil {
+		utils.NotFound(c, "game not found")
+		return
+	}
+
+	scope, ok := leaderboardScope(c, event)
+	if !ok {
+		return
+	}
+	scope.GameIDs = []uint{game.ID}
+
+	respondLeaderboard(c, event, scope)
 }
 
-func GetLeaderboard(c *gin.Context) {
+// leaderboardScope reads the optional games (comma-separated game IDs) and
+// division query parameters.
+func leaderboardScope(c *gin.Context, event models.Event) (scoring.Scope, bool) {
+	var scope scoring.Scope
+
+	if raw := c.Query("games"); raw != "" {
+		seen := make(map[uint]bool)
+		for _, part := range strings.Split(raw, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				utils.BadRequest(c, "invalid games")
+				return scope, false
+			}
+			if !seen[uint(id)] {
+				seen[uint(id)] = true
+				scope.GameIDs = append(scope.GameIDs, uint(id))
+			}
+		}
+
+		var count int64
+		database.DB.Model(&models.Game{}).Where("id IN ? AND event_id = ?", scope.GameIDs, event.ID).Count(&count)
+		if int(count) != len(scope.GameIDs) {
+			utils.BadRequest(c, "invalid game")
+			return scope, false
+		}
+	}
+
+	if division := c.Query("division"); division != "" {
+		var count int64
+		database.DB.Model(&models.Group{}).Where("event_id = ? AND division = ?", event.ID, division).Count(&count)
+		if count == 0 {
+			utils.NotFound(c, "division not found")
+			return scope, false
+		}
+		scope.Division = division
+	}
+
+	return scope, true
+}
+
+func respondLeaderboard(c *gin.Context, event models.Event, scope scoring.Scope) {
+	// Per-game, division and earlier standings would give away the
+	// unrevealed places.
+	if (!scope.Whole() || c.Query("as_of") != "") && revealHidden(c, event) {
+		utils.Forbidden(c, "standings are being revealed")
+		return
+	}
+
+	var window time.Duration
+	if raw := c.Query("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			utils.BadRequest(c, "invalid window")
+			return
+		}
+		window = parsed
+	}
+
+	cutoff, ok := viewCutoff(c, event)
+	if !ok {
+		return
+	}
+
+	var leaderboard []scoring.LeaderboardEntry
+	if revealed, ok := revealedEntries(c, event, window); ok {
+		leaderboard = revealed
+	} else {
+		leaderboard = scoring.ScopedLeaderboard(event, scope, cutoff, window)
+	}
+
+	utils.SuccessResponse(c, 200, leaderboard)
+}
+
+func GetIndividualLeaderboard(c *gin.Context) {
 	slug := c.Param("slug")
 
 	var event models.Event
@@ -33,6 +143,24 @@ func GetLeaderboard(c *gin.Context) {
 
 	var groups []models.Group
 	database.DB.Where("event_id = ?", event.ID).Order("sort_order").Find(&groups)
+	if revealed, ok := revealedEntries(c, event, 0); ok {
+		shown := revealedGroups(revealed)
+		visible := make([]models.Group, 0, len(shown))
+		for _, g := range groups {
+			if shown[g.ID] {
+				visible = append(visible, g)
+			}
+		}
+		groups = visible
+	}
+
+	groupIDs := make([]uint, len(groups))
+	for i, g := range groups {
+		groupIDs[i] = g.ID
+	}
+
+	var participants []models.Participant
+	database.DB.Where("group_id IN ?", groupIDs).Order("name").Find(&participants)
 
 	var games []models.Game
 	database.DB.Where("event_id = ?", event.ID).Order("sort_order").Find(&games)
@@ -42,54 +170,131 @@ func GetLeaderboard(c *gin.Context) {
 	for i, g := range games {
 		gameIDs[i] = g.ID
 	}
-	database.DB.Where("game_id IN ?", gameIDs).Find(&scores)
+	cutoff, ok := viewCutoff(c, event)
+	if !ok {
+		return
+	}
+	query := database.DB.Where("game_id IN ? AND participant_id IS NOT NULL", gameIDs)
+	if cutoff != nil {
+		query = scoring.ExistedAt(query, *cutoff)
+	}
+	query.Find(&scores)
+	if cutoff != nil {
+		scoring.RestoreScores(scores, *cutoff)
+	}
 
-	gameMap := make(map[uint]models.Game)
-	for _, g := range games {
-		gameMap[g.ID] = g
+	leaderboard := scoring.BuildIndividualLeaderboard(groups, participants, games, scores)
+
+	utils.SuccessResponse(c, 200, leaderboard)
+}
+
+func GetLeaderboardHistory(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var event models.Event
+	result := database.DB.Where("slug = ?", slug).First(&event)
+	if result.Error != nil {
+		utils.NotFound(c, "event not found")
+		return
 	}
 
-	groupScores := make(map[uint]map[uint]int)
-	for _, s := range scores {
-		if groupScores[s.GroupID] == nil {
-			groupScores[s.GroupID] = make(map[uint]int)
+	var interval time.Duration
+	if raw := c.Query("interval"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			utils.BadRequest(c, "invalid interval")
+			return
 		}
-		groupScores[s.GroupID][s.GameID] += s.Value
+		interval = parsed
 	}
 
-	leaderboard := make([]LeaderboardEntry, 0, len(groups))
-	for _, group := range groups {
-		entry := LeaderboardEntry{
-			GroupID:      group.ID,
-			GroupName:    group.Name,
-			GroupColor:   group.Color,
-			TotalScore:   0,
-			ScoresByGame: []GameScore{},
-		}
+	cutoff, ok := viewCutoff(c, event)
+	if !ok {
+		return
+	}
 
-		for _, game := range games {
-			score := 0
-			if groupScores[group.ID] != nil {
-				score = groupScores[group.ID][game.ID]
+	timelines := scoring.EventTimeline(event, interval, cutoff)
+	if revealed, ok := revealedEntries(c, event, 0); ok {
+		shown := revealedGroups(revealed)
+		visible := make([]scoring.GroupTimeline, 0, len(shown))
+		for _, t := range timelines {
+			if shown[t.GroupID] {
+				visible = append(visible, t)
 			}
-			entry.ScoresByGame = append(entry.ScoresByGame, GameScore{
-				GameID:   game.ID,
-				GameName: game.Name,
-				Score:    score,
-			})
-			entry.TotalScore += score
 		}
+		timelines = visible
+	}
 
-		leaderboard = append(leaderboard, entry)
+	utils.SuccessResponse(c, 200, timelines)
+}
+
+// revealedEntries returns the positions of a staged reveal shown so far,
+// counted from last place in the order fixed when the reveal started, when
+// the viewer is not signed in. ok is false when there is no reveal in
+// progress or the viewer can see everything.
+func revealedEntries(c *gin.Context, event models.Event, window time.Duration) ([]scoring.LeaderboardEntry, bool) {
+	if !revealHidden(c, event) {
+		return nil, false
 	}
+	order := event.RevealOrder
+	shown := order[len(order)-min(event.RevealStep, len(order)):]
 
-	for i := 0; i < len(leaderboard)-1; i++ {
-		for j := i + 1; j < len(leaderboard); j++ {
-			if leaderboard[j].TotalScore > leaderboard[i].TotalScore {
-				leaderboard[i], leaderboard[j] = leaderboard[j], leaderboard[i]
-			}
+	index := make(map[uint]scoring.LeaderboardEntry)
+	for _, e := range scoring.EventLeaderboardSince(event, nil, window) {
+		index[e.GroupID] = e
+	}
+	revealed := make([]scoring.LeaderboardEntry, 0, len(shown))
+	for _, id := range shown {
+		if e, ok := index[uint(id)]; ok {
+			revealed = append(revealed, e)
 		}
 	}
+	return revealed, true
+}
 
-	utils.SuccessResponse(c, 200, leaderboard)
+// revealHidden reports whether a staged reveal is hiding part of the
+// standings from the viewer.
+func revealHidden(c *gin.Context, event models.Event) bool {
+	return event.Revealing && middleware.GetUserID(c) == 0
+}
+
+func revealedGroups(entries []scoring.LeaderboardEntry) map[uint]bool {
+	groups := make(map[uint]bool, len(entries))
+	for _, e := range entries {
+		groups[e.GroupID] = true
+	}
+	return groups
+}
+
+// publicCutoff returns the freeze time when the event's standings are frozen
+// and the viewer is not signed in. Signed-in users keep seeing live results.
+func publicCutoff(c *gin.Context, event models.Event) *time.Time {
+	if event.FrozenAt == nil || middleware.GetUserID(c) != 0 {
+		return nil
+	}
+	return event.FrozenAt
+}
+
+// viewCutoff returns the moment a request sees the standings at: the as_of
+// query parameter (RFC 3339) when given, held back to the freeze time for
+// anonymous viewers of a frozen event.
+func viewCutoff(c *gin.Context, event models.Event) (*time.Time, bool) {
+	cutoff := publicCutoff(c, event)
+
+	raw := c.Query("as_of")
+	if raw == "" {
+		return cutoff, true
+	}
+	asOf, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		utils.BadRequest(c, "invalid as_of")
+		return nil, false
+	}
+	// Timestamps are stored in server time and compared as text.
+	asOf = asOf.Local()
+
+	if cutoff != nil && cutoff.Before(asOf) {
+		return cutoff, true
+	}
+	return &asOf, true
 }
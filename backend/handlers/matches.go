@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scoresystem/backend/database"
+	"github.com/scoresystem/backend/middleware"
+	"github.com/scoresystem/backend/models"
+	"github.com/scoresystem/backend/scoring"
+	"github.com/scoresystem/backend/tournament"
+	"github.com/scoresystem/backend/utils"
+	"github.com/scoresystem/backend/websocket"
+	"gorm.io/gorm"
+)
+
+type CreateMatchRequest struct {
+	GroupIDs []uint `json:"group_ids" binding:"required,min=2"`
+	Round    int    `json:"round"`
+	Label    string `json:"label"`
+}
+
+type MatchResultEntry struct {
+	GroupID uint     `json:"group_id" binding:"required"`
+	Score   *float64 `json:"score"`
+}
+
+type MatchResultRequest struct {
+	Entries  []MatchResultEntry `json:"entries" binding:"required,min=1,dive"`
+	WinnerID *uint              `json:"winner_id"`
+	Draw     bool               `json:"draw"`
+	Note     string             `json:"note"`
+}
+
+func ListGameMatches(c *gin.Context) {
+	slug := c.Param("slug")
+	gameID := c.Param("gameId")
+
+	var event models.Event
+	result := database.DB.Where("slug = ?", slug).First(&event)
+	if result.Error != nil {
+		utils.NotFound(c, "event not found")
+		return
+	}
+
+	var game models.Game
+	result = database.DB.Where("id = ? AND event_id = ?", gameID, event.ID).First(&game)
+	if result.Error != nil {
+		utils.NotFound(c, "game not found")
+		return
+	}
+
+	var matches []models.Match
+	database.DB.Where("game_id = ?", game.ID).
+		Preload("Entries.Group").
+		Order("round, id").
+		Find(&matches)
+	if cutoff := publicCutoff(c, event); cutoff != nil {
+		matches = matchesAt(matches, *cutoff)
+	}
+
+	utils.SuccessResponse(c, 200, matches)
+}
+
+// matchesAt shows matches as they stood at a moment, for anonymous viewers
+// of a frozen event. Matches drawn up since are left out, along with groups
+// that were advanced into a match since, and results recorded since are
+// shown as pending. A result re-recorded since is hidden with them.
+func matchesAt(matches []models.Match, at time.Time) []models.Match {
+	shown := make([]models.Match, 0, len(matches))
+	for _, m := range matches {
+		if m.CreatedAt.After(at) {
+			continue
+		}
+		decided := m.Status != "completed" || !m.UpdatedAt.After(at)
+		if !decided {
+			m.Status = "pending"
+			m.WinnerID = nil
+		}
+		entries := make([]models.MatchEntry, 0, len(m.Entries))
+		for _, e := range m.Entries {
+			if e.CreatedAt.After(at) {
+				continue
+			}
+			if !decided {
+				e.Score = nil
+				e.Outcome = ""
+			}
+			entries = append(entries, e)
+		}
+		m.Entries = entries
+		shown = append(shown, m)
+	}
+	return shown
+}
+
+func CreateMatch(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	gameID := c.Param("id")
+
+	var game models.Game
+	result := database.DB.Preload("Event").First(&game, gameID)
+	if result.Error != nil {
+		utils.NotFound(c, "game not found")
+		return
+	}
+
+	if game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	if game.ScoringMode != scoring.ModeMatch {
+		utils.BadRequest(c, "game is not scored by matches")
+		return
+	}
+
+	var req CreateMatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "invalid request body")
+		return
+	}
+
+	seen := make(map[uint]bool)
+	for _, id := range req.GroupIDs {
+		if seen[id] {
+			utils.BadRequest(c, "each group can only play once in a match")
+			return
+		}
+		seen[id] = true
+	}
+
+	var count int64
+	database.DB.Model(&models.Group{}).Where("id IN ? AND event_id = ?", req.GroupIDs, game.EventID).Count(&count)
+	if int(count) != len(req.GroupIDs) {
+		utils.BadRequest(c, "invalid group")
+		return
+	}
+
+	match := models.Match{
+		GameID:    game.ID,
+		Round:     req.Round,
+		Label:     req.Label,
+		Status:    "pending",
+		CreatedBy: userID,
+	}
+	for _, id := range req.GroupIDs {
+		match.Entries = append(match.Entries, models.MatchEntry{GroupID: id})
+	}
+
+	if result := database.DB.Create(&match); result.Error != nil {
+		utils.InternalError(c, "failed to create match")
+		return
+	}
+
+	utils.SuccessResponse(c, 201, match)
+}
+
+func RecordMatchResult(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	matchID := c.Param("id")
+
+	var match models.Match
+	result := database.DB.Preload("Game.Event").Preload("Entries").First(&match, matchID)
+	if result.Error != nil {
+		utils.NotFound(c, "match not found")
+		return
+	}
+
+	if match.Game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	var req MatchResultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "invalid request body")
+		return
+	}
+
+	if req.Draw && req.WinnerID != nil {
+		utils.BadRequest(c, "a match cannot have both a winner and a draw")
+		return
+	}
+
+	if match.Bye {
+		utils.BadRequest(c, "byes have no result to record")
+		return
+	}
+
+	if match.BracketID != nil {
+		if len(match.Entries) < 2 {
+			utils.BadRequest(c, "match is still waiting for its groups")
+			return
+		}
+		if tournament.Locked(database.DB, match) {
+			utils.BadRequest(c, "a later match in the bracket has already been decided")
+			return
+		}
+	}
+
+	submitted := make(map[uint]*float64, len(req.Entries))
+	for _, e := range req.Entries {
+		if _, ok := submitted[e.GroupID]; ok {
+			utils.BadRequest(c, "each group can only be reported once")
+			return
+		}
+		submitted[e.GroupID] = e.Score
+	}
+	if len(submitted) != len(match.Entries) {
+		utils.BadRequest(c, "a result is needed for every group in the match")
+		return
+	}
+	for i := range match.Entries {
+		score, ok := submitted[match.Entries[i].GroupID]
+		if !ok {
+			utils.BadRequest(c, "group is not part of the match")
+			return
+		}
+		if score != nil {
+			rounded := scoring.Round(*score, match.Game.Decimals)
+			score = &rounded
+		}
+		match.Entries[i].Score = score
+	}
+
+	outcomes, winnerID, err := scoring.MatchOutcomes(match.Game, match.Entries, req.WinnerID, req.Draw)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if match.BracketID != nil && winnerID == nil {
+		utils.BadRequest(c, "bracket matches need a winner")
+		return
+	}
+
+	scores := make([]models.Score, len(match.Entries))
+	for i := range match.Entries {
+		entry := &match.Entries[i]
+		entry.Outcome = outcomes[entry.GroupID]
+		scores[i] = models.Score{
+			GameID:    match.GameID,
+			GroupID:   entry.GroupID,
+			MatchID:   &match.ID,
+			Value:     models.NewDecimal(scoring.OutcomePoints(match.Game, entry.Outcome)),
+			Category:  scoring.CategoryBase,
+			Note:      req.Note,
+			CreatedBy: userID,
+		}
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if match.BracketID != nil && match.Status == "completed" {
+			if err := tournament.Withdraw(tx, match); err != nil {
+				return err
+			}
+		}
+		for _, entry := range match.Entries {
+			if err := tx.Model(&entry).Updates(map[string]interface{}{
+				"score":   entry.Score,
+				"outcome": entry.Outcome,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&match).Updates(map[string]interface{}{
+			"status":    "completed",
+			"winner_id": winnerID,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("match_id = ?", match.ID).Delete(&models.Score{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&scores).Error; err != nil {
+			return err
+		}
+		if match.BracketID != nil {
+			match.WinnerID = winnerID
+			return tournament.Advance(tx, match)
+		}
+		if match.ScheduleID != nil {
+			return tournament.FinishSchedule(tx, *match.ScheduleID)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to record match result")
+		return
+	}
+
+	scoring.InvalidateLeaderboard(match.Game.EventID)
+	for _, score := range scores {
+		websocket.BroadcastScoreUpdate(match.Game.EventID, score, scoring.GroupGameScore(match.Game, score.GroupID))
+	}
+
+	database.DB.Preload("Entries.Group").First(&match, match.ID)
+
+	utils.SuccessResponse(c, 200, match)
+}
+
+func DeleteMatch(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	matchID := c.Param("id")
+
+	var match models.Match
+	result := database.DB.Preload("Game.Event").First(&match, matchID)
+	if result.Error != nil {
+		utils.NotFound(c, "match not found")
+		return
+	}
+
+	if match.Game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	if match.BracketID != nil {
+		utils.BadRequest(c, "bracket matches are removed with their bracket")
+		return
+	}
+	if match.ScheduleID != nil {
+		utils.BadRequest(c, "scheduled matches are removed with their schedule")
+		return
+	}
+
+	var scores []models.Score
+	database.DB.Where("match_id = ?", match.ID).Find(&scores)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("match_id = ?", match.ID).Delete(&models.Score{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("match_id = ?", match.ID).Delete(&models.MatchEntry{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&match).Error
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to delete match")
+		return
+	}
+
+	scoring.InvalidateLeaderboard(match.Game.EventID)
+	for _, score := range scores {
+		websocket.BroadcastScoreDelete(match.Game.EventID, score, scoring.GroupGameScore(match.Game, score.GroupID))
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{"message": "match deleted"})
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/scoresystem/backend/database"
+	"github.com/scoresystem/backend/middleware"
+	"github.com/scoresystem/backend/models"
+	"github.com/scoresystem/backend/scoring"
+	"github.com/scoresystem/backend/tournament"
+	"github.com/scoresystem/backend/utils"
+	"github.com/scoresystem/backend/websocket"
+	"gorm.io/gorm"
+)
+
+type CreateScheduleRequest struct {
+	Format   string `json:"format" binding:"required"`
+	Rounds   int    `json:"rounds"`
+	GroupIDs []uint `json:"group_ids"`
+}
+
+type ScheduleTree struct {
+	models.Schedule
+	Rounds []MatchRound `json:"rounds"`
+}
+
+func GetGameSchedule(c *gin.Context) {
+	slug := c.Param("slug")
+	gameID := c.Param("gameId")
+
+	var event models.Event
+	result := database.DB.Where("slug = ?", slug).First(&event)
+	if result.Error != nil {
+		utils.NotFound(c, "event not found")
+		return
+	}
+
+	var schedule models.Schedule
+	result = database.DB.Joins("JOIN games ON games.id = schedules.game_id").
+		Where("schedules.game_id = ? AND games.event_id = ?", gameID, event.ID).
+		First(&schedule)
+	if result.Error != nil {
+		utils.NotFound(c, "schedule not found")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, scheduleTree(schedule, publicCutoff(c, event)))
+}
+
+func CreateSchedule(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	gameID := c.Param("id")
+
+	var game models.Game
+	result := database.DB.Preload("Event").First(&game, gameID)
+	if result.Error != nil {
+		utils.NotFound(c, "game not found")
+		return
+	}
+
+	if game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	if game.ScoringMode != scoring.ModeMatch {
+		utils.BadRequest(c, "game is not scored by matches")
+		return
+	}
+
+	var req CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "invalid request body")
+		return
+	}
+
+	if !tournament.ValidFormat(req.Format) {
+		utils.BadRequest(c, "invalid schedule format")
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.Schedule{}).Where("game_id = ?", game.ID).Count(&existing)
+	if existing > 0 {
+		utils.BadRequest(c, "game already has a schedule")
+		return
+	}
+
+	seen := make(map[uint]bool)
+	for _, id := range req.GroupIDs {
+		if seen[id] {
+			utils.BadRequest(c, "each group can only be scheduled once")
+			return
+		}
+		seen[id] = true
+	}
+	if len(req.GroupIDs) > 0 {
+		var count int64
+		database.DB.Model(&models.Group{}).Where("id IN ? AND event_id = ?", req.GroupIDs, game.EventID).Count(&count)
+		if int(count) != len(req.GroupIDs) {
+			utils.BadRequest(c, "invalid group")
+			return
+		}
+	}
+
+	// Groups are seeded by the event standings so that the first Swiss
+	// round pairs neighbours in the table.
+	var seeds []uint
+	for _, entry := range scoring.EventLeaderboard(game.Event, nil) {
+		if len(req.GroupIDs) == 0 || seen[entry.GroupID] {
+			seeds = append(seeds, entry.GroupID)
+		}
+	}
+	if len(seeds) < 2 {
+		utils.BadRequest(c, "not enough groups for a schedule")
+		return
+	}
+
+	allRounds := tournament.RoundRobin(seeds)
+	rounds := len(allRounds)
+	if req.Format == tournament.FormatSwiss {
+		rounds = tournament.SwissRounds(len(seeds))
+		if req.Rounds != 0 {
+			rounds = req.Rounds
+		}
+		if rounds < 1 || rounds > len(allRounds) {
+			utils.BadRequest(c, "invalid number of rounds")
+			return
+		}
+	}
+
+	groupIDs := make(models.IntList, len(seeds))
+	for i, id := range seeds {
+		groupIDs[i] = int(id)
+	}
+
+	schedule := models.Schedule{
+		GameID:      game.ID,
+		Format:      req.Format,
+		TotalRounds: rounds,
+		GroupIDs:    groupIDs,
+		Status:      "active",
+		CreatedBy:   userID,
+	}
+
+	var scores []models.Score
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&schedule).Error; err != nil {
+			return err
+		}
+		if req.Format == tournament.FormatSwiss {
+			allRounds = [][]tournament.Pairing{tournament.SwissPairings(seeds, nil, nil)}
+		}
+		for i, pairings := range allRounds {
+			created, err := createRound(tx, game, schedule, i+1, pairings, userID)
+			if err != nil {
+				return err
+			}
+			scores = append(scores, created...)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to create schedule")
+		return
+	}
+
+	scoring.InvalidateLeaderboard(game.EventID)
+	for _, score := range scores {
+		websocket.BroadcastScoreUpdate(game.EventID, score, scoring.GroupGameScore(game, score.GroupID))
+	}
+
+	utils.SuccessResponse(c, 201, scheduleTree(schedule, nil))
+}
+
+func NextScheduleRound(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	scheduleID := c.Param("id")
+
+	var schedule models.Schedule
+	result := database.DB.Preload("Game.Event").First(&schedule, scheduleID)
+	if result.Error != nil {
+		utils.NotFound(c, "schedule not found")
+		return
+	}
+
+	if schedule.Game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	if schedule.Format != tournament.FormatSwiss {
+		utils.BadRequest(c, "only swiss schedules are generated round by round")
+		return
+	}
+
+	var matches []models.Match
+	database.DB.Where("schedule_id = ?", schedule.ID).Preload("Entries").Find(&matches)
+
+	current := 0
+	for _, m := range matches {
+		if m.Status != "completed" {
+			utils.BadRequest(c, "the current round is not finished")
+			return
+		}
+		if m.Round > current {
+			current = m.Round
+		}
+	}
+	if current >= schedule.TotalRounds {
+		utils.BadRequest(c, "all rounds have been generated")
+		return
+	}
+
+	var scores []models.Score
+	database.DB.Where("match_id IN (?)", database.DB.Model(&models.Match{}).Select("id").Where("schedule_id = ?", schedule.ID)).
+		Find(&scores)
+	points := make(map[uint]models.Decimal)
+	for _, s := range scores {
+		points[s.GroupID] += s.Value
+	}
+
+	played := make(map[[2]uint]bool)
+	hadBye := make(map[uint]bool)
+	for _, m := range matches {
+		if m.Bye {
+			for _, e := range m.Entries {
+				hadBye[e.GroupID] = true
+			}
+			continue
+		}
+		for i := range m.Entries {
+			for j := i + 1; j < len(m.Entries); j++ {
+				played[tournament.PairKey(m.Entries[i].GroupID, m.Entries[j].GroupID)] = true
+			}
+		}
+	}
+
+	standings := make([]uint, len(schedule.GroupIDs))
+	for i, id := range schedule.GroupIDs {
+		standings[i] = uint(id)
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		return points[standings[i]] > points[standings[j]]
+	})
+
+	pairings := tournament.SwissPairings(standings, played, hadBye)
+
+	var created []models.Score
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, err = createRound(tx, schedule.Game, schedule, current+1, pairings, userID)
+		if err != nil {
+			return err
+		}
+		return tournament.FinishSchedule(tx, schedule.ID)
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to create round")
+		return
+	}
+
+	scoring.InvalidateLeaderboard(schedule.Game.EventID)
+	for _, score := range created {
+		websocket.BroadcastScoreUpdate(schedule.Game.EventID, score, scoring.GroupGameScore(schedule.Game, score.GroupID))
+	}
+
+	database.DB.First(&schedule, schedule.ID)
+
+	utils.SuccessResponse(c, 201, scheduleTree(schedule, nil))
+}
+
+func DeleteSchedule(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	scheduleID := c.Param("id")
+
+	var schedule models.Schedule
+	result := database.DB.Preload("Game.Event").First(&schedule, scheduleID)
+	if result.Error != nil {
+		utils.NotFound(c, "schedule not found")
+		return
+	}
+
+	if schedule.Game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	matchIDs := database.DB.Model(&models.Match{}).Select("id").Where("schedule_id = ?", schedule.ID)
+
+	var scores []models.Score
+	database.DB.Where("match_id IN (?)", matchIDs).Find(&scores)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		ids := tx.Model(&models.Match{}).Select("id").Where("schedule_id = ?", schedule.ID)
+		if err := tx.Where("match_id IN (?)", ids).Delete(&models.Score{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("match_id IN (?)", ids).Delete(&models.MatchEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("schedule_id = ?", schedule.ID).Delete(&models.Match{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&schedule).Error
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to delete schedule")
+		return
+	}
+
+	scoring.InvalidateLeaderboard(schedule.Game.EventID)
+	for _, score := range scores {
+		websocket.BroadcastScoreDelete(schedule.Game.EventID, score, scoring.GroupGameScore(schedule.Game, score.GroupID))
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{"message": "schedule deleted"})
+}
+
+// createRound stores one round of pairings. Byes are kept as completed
+// single-group matches; in Swiss a bye counts as a win so the group sitting
+// out is not left behind in the standings, while in a round robin every
+// group rests equally often and a bye earns nothing.
+func createRound(tx *gorm.DB, game models.Game, schedule models.Schedule, round int, pairings []tournament.Pairing, userID uint) ([]models.Score, error) {
+	var scores []models.Score
+	for i, p := range pairings {
+		match := models.Match{
+			GameID:     game.ID,
+			ScheduleID: &schedule.ID,
+			Round:      round,
+			Position:   i,
+			Status:     "pending",
+			CreatedBy:  userID,
+			Entries:    []models.MatchEntry{{GroupID: p.Home}},
+		}
+		if p.Away != 0 {
+			match.Entries = append(match.Entries, models.MatchEntry{GroupID: p.Away})
+		} else {
+			match.Bye = true
+			match.Status = "completed"
+			if schedule.Format == tournament.FormatSwiss {
+				match.WinnerID = &p.Home
+				match.Entries[0].Outcome = scoring.OutcomeWin
+			}
+		}
+		if err := tx.Create(&match).Error; err != nil {
+			return nil, err
+		}
+
+		if match.Bye && schedule.Format == tournament.FormatSwiss {
+			score := models.Score{
+				GameID:    game.ID,
+				GroupID:   p.Home,
+				MatchID:   &match.ID,
+				Value:     models.NewDecimal(scoring.OutcomePoints(game, scoring.OutcomeWin)),
+				Category:  scoring.CategoryBase,
+				Note:      "bye",
+				CreatedBy: userID,
+			}
+			if err := tx.Create(&score).Error; err != nil {
+				return nil, err
+			}
+			scores = append(scores, score)
+		}
+	}
+	return scores, nil
+}
+
+// scheduleTree lays out a schedule's matches by round. With a cutoff the
+// schedule is shown as it stood then.
+func scheduleTree(schedule models.Schedule, cutoff *time.Time) ScheduleTree {
+	var matches []models.Match
+	database.DB.Where("schedule_id = ?", schedule.ID).
+		Preload("Entries.Group").
+		Order("round, position").
+		Find(&matches)
+	if cutoff != nil {
+		matches = matchesAt(matches, *cutoff)
+		if schedule.Status == "completed" && schedule.UpdatedAt.After(*cutoff) {
+			schedule.Status = "active"
+		}
+	}
+
+	tree := ScheduleTree{Schedule: schedule, Rounds: []MatchRound{}}
+	for _, m := range matches {
+		tree.Rounds = appendToRound(tree.Rounds, m)
+	}
+	return tree
+}
@@ -1,18 +1,40 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 	"github.com/scoresystem/backend/database"
 	"github.com/scoresystem/backend/middleware"
 	"github.com/scoresystem/backend/models"
+	"github.com/scoresystem/backend/scoring"
 	"github.com/scoresystem/backend/utils"
 	"github.com/scoresystem/backend/websocket"
+	"gorm.io/gorm"
 )
 
 type CreateScoreRequest struct {
-	GroupID uint   `json:"group_id" binding:"required"`
-	Value   int    `json:"value" binding:"required"`
-	Note    string `json:"note"`
+	GroupID       uint               `json:"group_id" binding:"required"`
+	ParticipantID *uint              `json:"participant_id"`
+	Value         *float64           `json:"value"`
+	Inputs        map[string]float64 `json:"inputs"`
+	Category      string             `json:"category"`
+	Note          string             `json:"note"`
+	Current       bool               `json:"current"`
+	Override      bool               `json:"override"`
+	HeatID        *uint              `json:"heat_id"`
+}
+
+type PlacementEntry struct {
+	GroupID   uint `json:"group_id" binding:"required"`
+	Placement int  `json:"placement" binding:"required,min=1"`
+}
+
+type SubmitPlacementsRequest struct {
+	Placements []PlacementEntry `json:"placements" binding:"required,min=1,dive"`
+	HeatID     *uint            `json:"heat_id"`
+	Note       string           `json:"note"`
 }
 
 func ListEventScores(c *gin.Context) {
@@ -29,16 +51,48 @@ func ListEventScores(c *gin.Context) {
 	database.DB.Where("event_id = ?", event.ID).Find(&games)
 
 	gameIDs := make([]uint, len(games))
+	judgedIDs := make([]uint, 0)
 	for i, g := range games {
 		gameIDs[i] = g.ID
+		if g.ScoringMode == scoring.ModeJudged {
+			judgedIDs = append(judgedIDs, g.ID)
+		}
+	}
+
+	query := database.DB.Where("game_id IN ?", gameIDs)
+	// Individual judges' marks are only visible to admins.
+	if len(judgedIDs) > 0 {
+		query = query.Where("NOT (game_id IN ? AND category = ?)", judgedIDs, scoring.CategoryBase)
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+	cutoff, ok := viewCutoff(c, event)
+	if !ok {
+		return
+	}
+	if cutoff != nil {
+		query = scoring.ExistedAt(query, *cutoff)
+	}
+	if revealed, ok := revealedEntries(c, event, 0); ok {
+		groupIDs := make([]uint, len(revealed))
+		for i, e := range revealed {
+			groupIDs[i] = e.GroupID
+		}
+		query = query.Where("group_id IN ?", groupIDs)
 	}
 
 	var scores []models.Score
-	database.DB.Where("game_id IN ?", gameIDs).
-		Preload("Group").
+	query.Preload("Group").
+		Preload("Participant").
 		Preload("Game").
 		Order("created_at desc").
 		Find(&scores)
+	if cutoff != nil {
+		scoring.RestoreScores(scores, *cutoff)
+	}
+
+	scoring.MarkCounted(games, scores)
 
 	utils.SuccessResponse(c, 200, scores)
 }
@@ -54,7 +108,7 @@ func CreateScore(c *gin.Context) {
 		return
 	}
 
-	if game.Event.CreatedBy != userID {
+	if game.Event.CreatedBy != userID && !isJudge(game, userID) {
 		utils.Forbidden(c, "access denied")
 		return
 	}
@@ -65,6 +119,28 @@ func CreateScore(c *gin.Context) {
 		return
 	}
 
+	category := scoring.CategoryBase
+	if req.Category != "" {
+		category = req.Category
+	}
+	if !scoring.ValidCategory(category) {
+		utils.BadRequest(c, "invalid category")
+		return
+	}
+	if !canRecord(game, userID, category) {
+		utils.Forbidden(c, "judges can only record base marks")
+		return
+	}
+
+	if game.ScoringMode == scoring.ModePlacement && category == scoring.CategoryBase {
+		utils.BadRequest(c, "placement games are scored by submitting a ranking")
+		return
+	}
+	if game.ScoringMode == scoring.ModeMatch && category == scoring.CategoryBase {
+		utils.BadRequest(c, "match games are scored by recording match results")
+		return
+	}
+
 	var group models.Group
 	result = database.DB.Where("id = ? AND event_id = ?", req.GroupID, game.EventID).First(&group)
 	if result.Error != nil {
@@ -72,12 +148,42 @@ func CreateScore(c *gin.Context) {
 		return
 	}
 
+	if !validParticipant(req.ParticipantID, req.GroupID) {
+		utils.BadRequest(c, "participant does not belong to group")
+		return
+	}
+
+	if err := checkHeat(game, req.HeatID, category, req.GroupID); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	value, inputs, err := scoreValue(game, category, req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
 	score := models.Score{
-		GameID:    game.ID,
-		GroupID:   req.GroupID,
-		Value:     req.Value,
-		Note:      req.Note,
-		CreatedBy: userID,
+		GameID:        game.ID,
+		GroupID:       req.GroupID,
+		ParticipantID: req.ParticipantID,
+		HeatID:        req.HeatID,
+		Value:         value,
+		Inputs:        inputs,
+		Category:      category,
+		Note:          req.Note,
+		Current:       req.Current && game.ScoringMode == scoring.ModeAbsolute && category == scoring.CategoryBase,
+		CreatedBy:     userID,
+	}
+
+	if req.Override && game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "only the event owner can override score rules")
+		return
+	}
+	if err := checkScoreRules(game, &score, req.Override); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
 	}
 
 	if result := database.DB.Create(&score); result.Error != nil {
@@ -85,13 +191,163 @@ func CreateScore(c *gin.Context) {
 		return
 	}
 
-	database.DB.Preload("Group").Preload("Game").First(&score, score.ID)
+	if score.Current {
+		clearCurrent(score, userID)
+	}
 
-	websocket.BroadcastScoreUpdate(game.EventID, score)
+	database.DB.Preload("Group").Preload("Participant").Preload("Game").First(&score, score.ID)
+
+	scoring.InvalidateLeaderboard(game.EventID)
+	websocket.BroadcastScoreUpdate(game.EventID, score, scoring.GroupGameScore(game, score.GroupID))
 
 	utils.SuccessResponse(c, 201, score)
 }
 
+type JudgeMark struct {
+	models.Score
+	JudgeName string `json:"judge_name"`
+}
+
+func ListGameMarks(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	gameID := c.Param("id")
+
+	var game models.Game
+	result := database.DB.Preload("Event").First(&game, gameID)
+	if result.Error != nil {
+		utils.NotFound(c, "game not found")
+		return
+	}
+
+	if game.Event.CreatedBy != userID && !isJudge(game, userID) {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	var scores []models.Score
+	database.DB.Where("game_id = ?", game.ID).
+		Preload("Group").
+		Preload("Participant").
+		Order("created_at desc").
+		Find(&scores)
+
+	scoring.MarkCounted([]models.Game{game}, scores)
+
+	judgeIDs := make([]uint, 0, len(scores))
+	for _, s := range scores {
+		judgeIDs = append(judgeIDs, s.CreatedBy)
+	}
+
+	var judges []models.User
+	database.DB.Where("id IN ?", judgeIDs).Find(&judges)
+
+	judgeNames := make(map[uint]string)
+	for _, j := range judges {
+		judgeNames[j.ID] = j.Name
+	}
+
+	marks := make([]JudgeMark, len(scores))
+	for i, s := range scores {
+		marks[i] = JudgeMark{Score: s, JudgeName: judgeNames[s.CreatedBy]}
+	}
+
+	utils.SuccessResponse(c, 200, marks)
+}
+
+func SubmitPlacements(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	gameID := c.Param("id")
+
+	var game models.Game
+	result := database.DB.Preload("Event").First(&game, gameID)
+	if result.Error != nil {
+		utils.NotFound(c, "game not found")
+		return
+	}
+
+	if game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "access denied")
+		return
+	}
+
+	if game.ScoringMode != scoring.ModePlacement {
+		utils.BadRequest(c, "game is not scored by placement")
+		return
+	}
+
+	var req SubmitPlacementsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "invalid request body")
+		return
+	}
+
+	groupIDs := make([]uint, 0, len(req.Placements))
+	placements := make([]int, 0, len(req.Placements))
+	seen := make(map[uint]bool)
+	for _, p := range req.Placements {
+		if seen[p.GroupID] {
+			utils.BadRequest(c, "each group can only be ranked once")
+			return
+		}
+		seen[p.GroupID] = true
+		groupIDs = append(groupIDs, p.GroupID)
+		placements = append(placements, p.Placement)
+	}
+
+	var count int64
+	database.DB.Model(&models.Group{}).Where("id IN ? AND event_id = ?", groupIDs, game.EventID).Count(&count)
+	if int(count) != len(groupIDs) {
+		utils.BadRequest(c, "invalid group")
+		return
+	}
+
+	for _, groupID := range groupIDs {
+		if err := checkHeat(game, req.HeatID, scoring.CategoryBase, groupID); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	points := scoring.PlacementPoints(game.PointsTable, game.TieMode, game.Decimals, placements)
+	scores := make([]models.Score, len(req.Placements))
+	for i, p := range req.Placements {
+		scores[i] = models.Score{
+			GameID:    game.ID,
+			GroupID:   p.GroupID,
+			HeatID:    req.HeatID,
+			Value:     models.NewDecimal(points[i]),
+			Placement: p.Placement,
+			Category:  scoring.CategoryBase,
+			Note:      req.Note,
+			CreatedBy: userID,
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("game_id = ? AND category = ?", game.ID, scoring.CategoryBase)
+		if req.HeatID != nil {
+			query = query.Where("heat_id = ?", *req.HeatID)
+		} else {
+			query = query.Where("heat_id IS NULL")
+		}
+		if err := query.Delete(&models.Score{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&scores).Error
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to save placements")
+		return
+	}
+
+	scoring.InvalidateLeaderboard(game.EventID)
+	for _, score := range scores {
+		websocket.BroadcastScoreUpdate(game.EventID, score, scoring.GroupGameScore(game, score.GroupID))
+	}
+
+	utils.SuccessResponse(c, 201, scores)
+}
+
 func UpdateScore(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	scoreID := c.Param("id")
@@ -114,15 +370,95 @@ func UpdateScore(c *gin.Context) {
 		return
 	}
 
+	category := score.Category
+	if req.Category != "" {
+		category = req.Category
+	}
+	if !scoring.ValidCategory(category) {
+		utils.BadRequest(c, "invalid category")
+		return
+	}
+	if !canRecord(score.Game, userID, category) {
+		utils.Forbidden(c, "judges can only record base marks")
+		return
+	}
+
+	if score.Game.ScoringMode == scoring.ModePlacement && (category == scoring.CategoryBase || score.Category == scoring.CategoryBase) {
+		utils.BadRequest(c, "placement games are scored by submitting a ranking")
+		return
+	}
+	if score.Game.ScoringMode == scoring.ModeMatch && (category == scoring.CategoryBase || score.Category == scoring.CategoryBase) {
+		utils.BadRequest(c, "match games are scored by recording match results")
+		return
+	}
+
+	if !validParticipant(req.ParticipantID, req.GroupID) {
+		utils.BadRequest(c, "participant does not belong to group")
+		return
+	}
+
+	heatID := score.HeatID
+	if req.HeatID != nil {
+		heatID = req.HeatID
+	}
+	if err := checkHeat(score.Game, heatID, category, req.GroupID); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	value, inputs, err := scoreValue(score.Game, category, req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	candidate := score
+	candidate.GroupID = req.GroupID
+	candidate.ParticipantID = req.ParticipantID
+	candidate.HeatID = heatID
+	candidate.Value = value
+	candidate.Category = category
+	candidate.Current = req.Current && score.Game.ScoringMode == scoring.ModeAbsolute && category == scoring.CategoryBase
+
+	if req.Override && score.Game.Event.CreatedBy != userID {
+		utils.Forbidden(c, "only the event owner can override score rules")
+		return
+	}
+	if err := checkScoreRules(score.Game, &candidate, req.Override); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
 	updates := make(map[string]interface{})
-	updates["group_id"] = req.GroupID
-	updates["value"] = req.Value
+	updates["group_id"] = candidate.GroupID
+	updates["participant_id"] = candidate.ParticipantID
+	updates["heat_id"] = candidate.HeatID
+	updates["value"] = candidate.Value
+	updates["inputs"] = inputs
+	updates["category"] = candidate.Category
 	updates["note"] = req.Note
+	updates["current"] = candidate.Current
+	updates["override"] = candidate.Override
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := reviseScores(tx, userID, score.ID); err != nil {
+			return err
+		}
+		return tx.Model(&score).Updates(updates).Error
+	})
+	if err != nil {
+		utils.InternalError(c, "failed to update score")
+		return
+	}
+
+	database.DB.Preload("Group").Preload("Participant").Preload("Game").First(&score, score.ID)
 
-	database.DB.Model(&score).Updates(updates)
-	database.DB.Preload("Group").Preload("Game").First(&score, score.ID)
+	if score.Current {
+		clearCurrent(score, userID)
+	}
 
-	websocket.BroadcastScoreUpdate(score.Game.EventID, score)
+	scoring.InvalidateLeaderboard(score.Game.EventID)
+	websocket.BroadcastScoreUpdate(score.Game.EventID, score, scoring.GroupGameScore(score.Game, score.GroupID))
 
 	utils.SuccessResponse(c, 200, score)
 }
@@ -143,10 +479,169 @@ func DeleteScore(c *gin.Context) {
 		return
 	}
 
+	if score.MatchID != nil {
+		utils.Conflict(c, "score comes from a match result; re-record the result or delete the match instead")
+		return
+	}
+	if score.Game.ScoringMode == scoring.ModePlacement && score.Category == scoring.CategoryBase {
+		utils.Conflict(c, "placement games are scored by submitting a ranking")
+		return
+	}
+
 	eventID := score.Game.EventID
 	database.DB.Delete(&score)
 
-	websocket.BroadcastScoreDelete(eventID, score.ID)
+	scoring.InvalidateLeaderboard(eventID)
+	websocket.BroadcastScoreDelete(eventID, score, scoring.GroupGameScore(score.Game, score.GroupID))
 
 	utils.SuccessResponse(c, 200, gin.H{"message": "score deleted"})
 }
+
+func clearCurrent(score models.Score, userID uint) {
+	query := database.DB.Model(&models.Score{}).
+		Where("game_id = ? AND group_id = ? AND id <> ? AND current = ?", score.GameID, score.GroupID, score.ID, true)
+	if score.ParticipantID != nil {
+		query = query.Where("participant_id = ?", *score.ParticipantID)
+	} else {
+		query = query.Where("participant_id IS NULL")
+	}
+	if score.HeatID != nil {
+		query = query.Where("heat_id = ?", *score.HeatID)
+	} else {
+		query = query.Where("heat_id IS NULL")
+	}
+
+	var ids []uint
+	query.Pluck("id", &ids)
+	if len(ids) == 0 {
+		return
+	}
+
+	database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := reviseScores(tx, userID, ids...); err != nil {
+			return err
+		}
+		return tx.Model(&models.Score{}).Where("id IN ?", ids).Update("current", false).Error
+	})
+}
+
+// reviseScores keeps the scores as they are before an edit, so the standings
+// at any earlier moment can be rebuilt.
+func reviseScores(tx *gorm.DB, userID uint, ids ...uint) error {
+	var scores []models.Score
+	if err := tx.Where("id IN ?", ids).Find(&scores).Error; err != nil {
+		return err
+	}
+
+	revisions := make([]models.ScoreRevision, len(scores))
+	for i, s := range scores {
+		revisions[i] = models.ScoreRevision{
+			ScoreID:       s.ID,
+			GroupID:       s.GroupID,
+			ParticipantID: s.ParticipantID,
+			HeatID:        s.HeatID,
+			Value:         s.Value,
+			Inputs:        s.Inputs,
+			Placement:     s.Placement,
+			Category:      s.Category,
+			Note:          s.Note,
+			Current:       s.Current,
+			Override:      s.Override,
+			RevisedBy:     userID,
+		}
+	}
+	if len(revisions) == 0 {
+		return nil
+	}
+	return tx.Create(&revisions).Error
+}
+
+// canRecord reports whether a user may record a score of the given category.
+// The event owner records anything; judges only give base marks in the
+// judged games they are assigned to.
+func canRecord(game models.Game, userID uint, category string) bool {
+	if game.Event.CreatedBy == userID {
+		return true
+	}
+	return category == scoring.CategoryBase && isJudge(game, userID)
+}
+
+// checkHeat makes sure a score names a heat of its game when the game is run
+// in heats, and that the group was assigned to that heat. Bonuses and
+// penalties may be given for the game as a whole.
+func checkHeat(game models.Game, heatID *uint, category string, groupID uint) error {
+	if heatID == nil {
+		if game.HeatCounting != "" && category == scoring.CategoryBase {
+			return errors.New("heat is required")
+		}
+		return nil
+	}
+	if game.HeatCounting == "" {
+		return errors.New("game is not run in heats")
+	}
+
+	var heat models.Heat
+	if err := database.DB.Where("id = ? AND game_id = ?", *heatID, game.ID).First(&heat).Error; err != nil {
+		return errors.New("invalid heat")
+	}
+	if len(heat.GroupIDs) == 0 {
+		return nil
+	}
+	for _, id := range heat.GroupIDs {
+		if uint(id) == groupID {
+			return nil
+		}
+	}
+	return errors.New("group is not in this heat")
+}
+
+func validParticipant(participantID *uint, groupID uint) bool {
+	if participantID == nil {
+		return true
+	}
+	var participant models.Participant
+	return database.DB.Where("id = ? AND group_id = ?", *participantID, groupID).First(&participant).Error == nil
+}
+
+// scoreValue works out the value to store for a score. Base scores in formula
+// games are computed from their raw inputs; everything else is entered
+// directly.
+func scoreValue(game models.Game, category string, req CreateScoreRequest) (models.Decimal, models.FloatMap, error) {
+	if game.Formula != "" && category == scoring.CategoryBase {
+		if req.Inputs == nil {
+			return 0, nil, errors.New("inputs are required for formula games")
+		}
+		known := make(map[string]bool, len(game.FormulaInputs))
+		for _, name := range game.FormulaInputs {
+			known[name] = true
+		}
+		for name := range req.Inputs {
+			if !known[name] {
+				return 0, nil, fmt.Errorf("unknown input %q", name)
+			}
+		}
+		value, err := scoring.FormulaValue(game, req.Inputs)
+		if err != nil {
+			return 0, nil, err
+		}
+		return models.NewDecimal(value), req.Inputs, nil
+	}
+	if req.Value == nil {
+		return 0, nil, errors.New("value is required")
+	}
+	return models.NewDecimal(scoring.Round(scoring.NormalizeValue(category, *req.Value), game.Decimals)), nil, nil
+}
+
+// checkScoreRules applies the game's limits to a score about to be saved.
+// Overridden scores skip the checks and keep the flag for auditing.
+func checkScoreRules(game models.Game, score *models.Score, override bool) error {
+	score.Override = override
+	if override {
+		return nil
+	}
+
+	var others []models.Score
+	database.DB.Where("game_id = ? AND id <> ?", game.ID, score.ID).Find(&others)
+
+	return scoring.CheckScore(game, *score, others)
+}
@@ -38,9 +38,16 @@ func main() {
 		api.GET("/events/:slug", handlers.GetEventBySlug)
 		api.GET("/events/:slug/groups", handlers.ListEventGroups)
 		api.GET("/events/:slug/games", handlers.ListEventGames)
-		api.GET("/events/:slug/scores", handlers.ListEventScores)
-		api.GET("/events/:slug/leaderboard", handlers.GetLeaderboard)
-		api.GET("/events/:slug/ws", websocket.HandleWebSocket)
+		api.GET("/events/:slug/scores", middleware.OptionalAuth(), handlers.ListEventScores)
+		api.GET("/events/:slug/leaderboard", middleware.OptionalAuth(), handlers.GetLeaderboard)
+		api.GET("/events/:slug/leaderboard/individuals", middleware.OptionalAuth(), handlers.GetIndividualLeaderboard)
+		api.GET("/events/:slug/leaderboard/history", middleware.OptionalAuth(), handlers.GetLeaderboardHistory)
+		api.GET("/events/:slug/games/:gameId/leaderboard", middleware.OptionalAuth(), handlers.GetGameLeaderboard)
+		api.GET("/events/:slug/games/:gameId/matches", middleware.OptionalAuth(), handlers.ListGameMatches)
+		api.GET("/events/:slug/games/:gameId/bracket", middleware.OptionalAuth(), handlers.GetGameBracket)
+		api.GET("/events/:slug/games/:gameId/schedule", middleware.OptionalAuth(), handlers.GetGameSchedule)
+		api.GET("/events/:slug/games/:gameId/heats", handlers.ListGameHeats)
+		api.GET("/events/:slug/ws", middleware.SocketAuth(), websocket.HandleWebSocket)
 
 		admin := api.Group("/admin")
 		admin.Use(middleware.AuthRequired())
@@ -49,11 +56,16 @@ func main() {
 			admin.POST("/events", handlers.CreateEvent)
 			admin.PUT("/events/:id", handlers.UpdateEvent)
 			admin.DELETE("/events/:id", handlers.DeleteEvent)
+			admin.POST("/events/:id/freeze", handlers.FreezeEvent)
+			admin.POST("/events/:id/reveal", handlers.RevealEvent)
+			admin.POST("/events/:id/reveal/start", handlers.StartStagedReveal)
+			admin.POST("/events/:id/reveal/next", handlers.RevealNext)
 
 			admin.POST("/events/:id/groups", handlers.CreateGroup)
 			admin.PUT("/groups/:id", handlers.UpdateGroup)
 			admin.DELETE("/groups/:id", handlers.DeleteGroup)
 			admin.POST("/groups/:id/participants", handlers.CreateParticipant)
+			admin.PUT("/participants/:id", handlers.UpdateParticipant)
 			admin.DELETE("/participants/:id", handlers.DeleteParticipant)
 
 			admin.POST("/events/:id/games", handlers.CreateGame)
@@ -61,6 +73,19 @@ func main() {
 			admin.DELETE("/games/:id", handlers.DeleteGame)
 
 			admin.POST("/games/:id/scores", handlers.CreateScore)
+			admin.POST("/games/:id/placements", handlers.SubmitPlacements)
+			admin.GET("/games/:id/marks", handlers.ListGameMarks)
+			admin.POST("/games/:id/matches", handlers.CreateMatch)
+			admin.PUT("/matches/:id/result", handlers.RecordMatchResult)
+			admin.DELETE("/matches/:id", handlers.DeleteMatch)
+			admin.POST("/games/:id/bracket", handlers.CreateBracket)
+			admin.DELETE("/brackets/:id", handlers.DeleteBracket)
+			admin.POST("/games/:id/schedule", handlers.CreateSchedule)
+			admin.POST("/schedules/:id/rounds", handlers.NextScheduleRound)
+			admin.DELETE("/schedules/:id", handlers.DeleteSchedule)
+			admin.POST("/games/:id/heats", handlers.CreateHeat)
+			admin.PUT("/heats/:id", handlers.UpdateHeat)
+			admin.DELETE("/heats/:id", handlers.DeleteHeat)
 			admin.PUT("/scores/:id", handlers.UpdateScore)
 			admin.DELETE("/scores/:id", handlers.DeleteScore)
 
@@ -42,3 +42,35 @@ func GetUserID(c *gin.Context) uint {
 	}
 	return userID.(uint)
 }
+
+// OptionalAuth identifies signed-in users on public routes without turning
+// anyone away.
+func OptionalAuth() gin.HandlerFunc {
+	return optionalAuth(false)
+}
+
+// SocketAuth is OptionalAuth for the WebSocket route. Browsers cannot set
+// headers on WebSocket connections, so the token may also be passed as a
+// query parameter there, and only there, to keep it out of other URLs.
+func SocketAuth() gin.HandlerFunc {
+	return optionalAuth(true)
+}
+
+func optionalAuth(allowQuery bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := ""
+		if allowQuery {
+			token = c.Query("token")
+		}
+		if parts := strings.Split(c.GetHeader("Authorization"), " "); len(parts) == 2 && parts[0] == "Bearer" {
+			token = parts[1]
+		}
+
+		if token != "" {
+			if claims, err := utils.ValidateToken(token); err == nil {
+				c.Set("userID", claims.UserID)
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Bracket struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+	GameID     uint           `json:"game_id" gorm:"not null;index"`
+	Game       Game           `json:"game,omitempty" gorm:"foreignKey:GameID"`
+	Type       string         `json:"type" gorm:"not null"`           // single, double
+	Seeding    string         `json:"seeding" gorm:"not null"`        // leaderboard, manual
+	Status     string         `json:"status" gorm:"default:'active'"` // active, completed
+	ChampionID *uint          `json:"champion_id"`
+	CreatedBy  uint           `json:"created_by"`
+	Matches    []Match        `json:"matches,omitempty"`
+}
+
+func (Bracket) TableName() string {
+	return "brackets"
+}
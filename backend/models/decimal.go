@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DecimalPlaces is the precision score values are stored with.
+const DecimalPlaces = 3
+
+const decimalScale = 1000
+
+// Decimal is a score value held as a whole number of thousandths, so values
+// add up exactly, whether summed in Go or by the database.
+type Decimal int64
+
+// NewDecimal rounds a value to the nearest thousandth, half away from zero.
+func NewDecimal(value float64) Decimal {
+	return Decimal(math.Round(value * decimalScale))
+}
+
+// Float64 returns the value as the float closest to it.
+func (d Decimal) Float64() float64 {
+	return float64(d) / decimalScale
+}
+
+func (d Decimal) String() string {
+	sign := ""
+	units := int64(d)
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	whole := strconv.FormatInt(units/decimalScale, 10)
+	fraction := strings.TrimRight(strconv.FormatInt(decimalScale+units%decimalScale, 10)[1:], "0")
+	if fraction == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + fraction
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	var value *float64
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value != nil {
+		*d = NewDecimal(*value)
+	}
+	return nil
+}
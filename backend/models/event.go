@@ -7,17 +7,25 @@ import (
 )
 
 type Event struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
-	Name        string         `json:"name" gorm:"not null"`
-	Slug        string         `json:"slug" gorm:"uniqueIndex;not null"`
-	Description string         `json:"description"`
-	Status      string         `json:"status" gorm:"default:'draft'"` // draft, active, completed
-	CreatedBy   uint           `json:"created_by"`
-	Groups      []Group        `json:"groups,omitempty"`
-	Games       []Game         `json:"games,omitempty"`
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	Name           string         `json:"name" gorm:"not null"`
+	Slug           string         `json:"slug" gorm:"uniqueIndex;not null"`
+	Description    string         `json:"description"`
+	Status         string         `json:"status" gorm:"default:'draft'"` // draft, active, completed
+	CreatedBy      uint           `json:"created_by"`
+	Tiebreakers    StringList     `json:"tiebreakers" gorm:"type:text"` // most_wins, head_to_head, best_in_game, earliest
+	TiebreakGameID *uint          `json:"tiebreak_game_id"`
+	CategoryLabels StringMap      `json:"category_labels" gorm:"type:text"`
+	NormalizeSize  bool           `json:"normalize_size" gorm:"default:false"`
+	FrozenAt       *time.Time     `json:"frozen_at"`
+	Revealing      bool           `json:"revealing" gorm:"default:false"`
+	RevealStep     int            `json:"reveal_step" gorm:"default:0"`
+	RevealOrder    IntList        `json:"-" gorm:"type:text"` // group IDs in standings order; hidden since it gives the reveal away
+	Groups         []Group        `json:"groups,omitempty"`
+	Games          []Game         `json:"games,omitempty"`
 }
 
 func (Event) TableName() string {
@@ -7,18 +7,39 @@ import (
 )
 
 type Game struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
-	EventID     uint           `json:"event_id" gorm:"not null;index"`
-	Event       Event          `json:"event,omitempty" gorm:"foreignKey:EventID"`
-	Name        string         `json:"name" gorm:"not null"`
-	Description string         `json:"description"`
-	ScoringMode string         `json:"scoring_mode" gorm:"default:'incremental'"` // incremental, absolute
-	Status      string         `json:"status" gorm:"default:'pending'"`           // pending, active, completed
-	SortOrder   int            `json:"sort_order" gorm:"default:0"`
-	Scores      []Score        `json:"scores,omitempty"`
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+	EventID          uint           `json:"event_id" gorm:"not null;index"`
+	Event            Event          `json:"event,omitempty" gorm:"foreignKey:EventID"`
+	Name             string         `json:"name" gorm:"not null"`
+	Description      string         `json:"description"`
+	ScoringMode      string         `json:"scoring_mode" gorm:"default:'incremental'"` // incremental, absolute, placement, judged, match
+	PointsTable      IntList        `json:"points_table" gorm:"type:text"`
+	TieMode          string         `json:"tie_mode" gorm:"default:'average'"` // average, equal
+	Weight           float64        `json:"weight" gorm:"default:1"`
+	MetricType       string         `json:"metric_type" gorm:"default:'points'"`  // points, time, distance
+	SortDirection    string         `json:"sort_direction" gorm:"default:'desc'"` // desc, asc
+	Conversion       string         `json:"conversion"`                           // placement, relative
+	MaxPoints        int            `json:"max_points"`
+	Decimals         int            `json:"decimals" gorm:"default:0"`
+	Formula          string         `json:"formula"`
+	FormulaInputs    StringList     `json:"formula_inputs" gorm:"type:text"`
+	MinValue         *float64       `json:"min_value"`
+	MaxValue         *float64       `json:"max_value"`
+	MaxTotal         *float64       `json:"max_total"`
+	DisallowNegative bool           `json:"disallow_negative" gorm:"default:false"`
+	WinPoints        float64        `json:"win_points"`
+	DrawPoints       float64        `json:"draw_points"`
+	LossPoints       float64        `json:"loss_points"`
+	HeatCounting     string         `json:"heat_counting"` // best, sum, final
+	FinalHeatID      *uint          `json:"final_heat_id"`
+	Aggregation      string         `json:"aggregation" gorm:"default:'mean'"` // mean, median, trimmed_mean
+	JudgeIDs         IntList        `json:"judge_ids" gorm:"type:text"`
+	Status           string         `json:"status" gorm:"default:'pending'"` // pending, active, completed
+	SortOrder        int            `json:"sort_order" gorm:"default:0"`
+	Scores           []Score        `json:"scores,omitempty"`
 }
 
 func (Game) TableName() string {
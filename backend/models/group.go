@@ -15,6 +15,8 @@ type Group struct {
 	Event        Event          `json:"event,omitempty" gorm:"foreignKey:EventID"`
 	Name         string         `json:"name" gorm:"not null"`
 	Color        string         `json:"color"`
+	Handicap     float64        `json:"handicap"`
+	Division     string         `json:"division" gorm:"index"`
 	SortOrder    int            `json:"sort_order" gorm:"default:0"`
 	Participants []Participant  `json:"participants,omitempty"`
 	Scores       []Score        `json:"scores,omitempty"`
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Heat struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	GameID    uint           `json:"game_id" gorm:"not null;index"`
+	Game      Game           `json:"game,omitempty" gorm:"foreignKey:GameID"`
+	Name      string         `json:"name" gorm:"not null"`
+	GroupIDs  IntList        `json:"group_ids" gorm:"type:text"`
+	Status    string         `json:"status" gorm:"default:'pending'"` // pending, active, completed
+	SortOrder int            `json:"sort_order" gorm:"default:0"`
+}
+
+func (Heat) TableName() string {
+	return "heats"
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Match struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+	GameID       uint           `json:"game_id" gorm:"not null;index"`
+	Game         Game           `json:"game,omitempty" gorm:"foreignKey:GameID"`
+	BracketID    *uint          `json:"bracket_id" gorm:"index"`
+	ScheduleID   *uint          `json:"schedule_id" gorm:"index"`
+	Stage        string         `json:"stage"` // winners, losers, grand_final
+	Round        int            `json:"round" gorm:"default:0"`
+	Position     int            `json:"position" gorm:"default:0"`
+	Label        string         `json:"label"`
+	Bye          bool           `json:"bye"`
+	Status       string         `json:"status" gorm:"default:'pending'"` // pending, completed
+	WinnerID     *uint          `json:"winner_id"`
+	WinnerNextID *uint          `json:"winner_next_id"`
+	LoserNextID  *uint          `json:"loser_next_id"`
+	CreatedBy    uint           `json:"created_by"`
+	Entries      []MatchEntry   `json:"entries,omitempty"`
+}
+
+func (Match) TableName() string {
+	return "matches"
+}
+
+type MatchEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	MatchID   uint      `json:"match_id" gorm:"not null;index"`
+	GroupID   uint      `json:"group_id" gorm:"not null;index"`
+	Group     Group     `json:"group,omitempty" gorm:"foreignKey:GroupID"`
+	Seed      int       `json:"seed" gorm:"default:0"`
+	Score     *float64  `json:"score"`
+	Outcome   string    `json:"outcome"` // win, draw, loss
+}
+
+func (MatchEntry) TableName() string {
+	return "match_entries"
+}
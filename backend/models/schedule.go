@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Schedule struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	GameID      uint           `json:"game_id" gorm:"not null;index"`
+	Game        Game           `json:"game,omitempty" gorm:"foreignKey:GameID"`
+	Format      string         `json:"format" gorm:"not null"` // round_robin, swiss
+	TotalRounds int            `json:"total_rounds"`
+	GroupIDs    IntList        `json:"group_ids" gorm:"type:text"`
+	Status      string         `json:"status" gorm:"default:'active'"` // active, completed
+	CreatedBy   uint           `json:"created_by"`
+}
+
+func (Schedule) TableName() string {
+	return "schedules"
+}
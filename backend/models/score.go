@@ -7,17 +7,27 @@ import (
 )
 
 type Score struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
-	GameID    uint           `json:"game_id" gorm:"not null;index"`
-	Game      Game           `json:"game,omitempty" gorm:"foreignKey:GameID"`
-	GroupID   uint           `json:"group_id" gorm:"not null;index"`
-	Group     Group          `json:"group,omitempty" gorm:"foreignKey:GroupID"`
-	Value     int            `json:"value" gorm:"not null"`
-	Note      string         `json:"note"`
-	CreatedBy uint           `json:"created_by"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+	GameID        uint           `json:"game_id" gorm:"not null;index"`
+	Game          Game           `json:"game,omitempty" gorm:"foreignKey:GameID"`
+	GroupID       uint           `json:"group_id" gorm:"not null;index"`
+	Group         Group          `json:"group,omitempty" gorm:"foreignKey:GroupID"`
+	ParticipantID *uint          `json:"participant_id,omitempty" gorm:"index"`
+	Participant   *Participant   `json:"participant,omitempty" gorm:"foreignKey:ParticipantID"`
+	MatchID       *uint          `json:"match_id,omitempty" gorm:"index"`
+	HeatID        *uint          `json:"heat_id,omitempty" gorm:"index"`
+	Value         Decimal        `json:"value" gorm:"not null"`
+	Inputs        FloatMap       `json:"inputs,omitempty" gorm:"type:text"`
+	Placement     int            `json:"placement,omitempty"`
+	Category      string         `json:"category" gorm:"default:'base';index"` // base, bonus, penalty, adjustment
+	Note          string         `json:"note"`
+	Current       bool           `json:"current" gorm:"default:false"`
+	Override      bool           `json:"override" gorm:"default:false"`
+	Counted       bool           `json:"counted" gorm:"-"`
+	CreatedBy     uint           `json:"created_by"`
 }
 
 func (Score) TableName() string {
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// ScoreRevision is a score as it stood before an edit. CreatedAt is the time
+// of the edit, so the revision holds the score's state up to that moment.
+type ScoreRevision struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+	ScoreID       uint      `json:"score_id" gorm:"not null;index"`
+	GroupID       uint      `json:"group_id"`
+	ParticipantID *uint     `json:"participant_id,omitempty"`
+	HeatID        *uint     `json:"heat_id,omitempty"`
+	Value         Decimal   `json:"value"`
+	Inputs        FloatMap  `json:"inputs,omitempty" gorm:"type:text"`
+	Placement     int       `json:"placement,omitempty"`
+	Category      string    `json:"category"`
+	Note          string    `json:"note"`
+	Current       bool      `json:"current"`
+	Override      bool      `json:"override"`
+	RevisedBy     uint      `json:"revised_by"`
+}
+
+func (ScoreRevision) TableName() string {
+	return "score_revisions"
+}
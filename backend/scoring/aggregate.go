@@ -0,0 +1,98 @@
+package scoring
+
+import (
+	"time"
+
+	"github.com/scoresystem/backend/database"
+	"github.com/scoresystem/backend/models"
+	"gorm.io/gorm"
+)
+
+var baseCategories = []string{"", CategoryBase}
+
+// timeLayouts are the formats SQLite hands back timestamps in once they have
+// gone through an aggregate and lost their column type.
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// summable reports whether every base score of a game adds straight into its
+// result, so the scores can be summed before they are loaded.
+func summable(game models.Game) bool {
+	return game.ScoringMode == ModeIncremental || game.ScoringMode == ModeMatch || game.ScoringMode == ""
+}
+
+// summedScores loads the scores of the given games for the standings, with
+// the database summing whatever only ever adds up: base scores of
+// incremental and match games, and every bonus, penalty and adjustment.
+// Each summed row stands for one group or participant in one heat and
+// category, and carries the time of its latest score. Base scores of the
+// other modes are loaded row by row since the engine picks among them.
+// Soft-deleted scores are left out by both queries. With asOf set, the
+// scores are as they stood at that moment: those deleted since are counted
+// again, and those edited since are loaded row by row and put back the way
+// they were.
+func summedScores(games []models.Game, asOf *time.Time) []models.Score {
+	var all, summed, picked []uint
+	for _, g := range games {
+		all = append(all, g.ID)
+		if summable(g) {
+			summed = append(summed, g.ID)
+		} else {
+			picked = append(picked, g.ID)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+
+	query := database.DB.Model(&models.Score{}).Where("game_id IN ?", all)
+
+	var revised []models.Score
+	if asOf != nil {
+		edited := database.DB.Model(&models.ScoreRevision{}).Select("score_id").Where("created_at > ?", *asOf)
+		query = ExistedAt(query, *asOf)
+		query.Session(&gorm.Session{}).Where("scores.id IN (?)", edited).Find(&revised)
+		RestoreScores(revised, *asOf)
+		query = query.Where("scores.id NOT IN (?)", edited)
+	}
+
+	var rows []struct {
+		models.Score
+		LatestAt string
+	}
+	query.Session(&gorm.Session{}).
+		Select("game_id, group_id, participant_id, heat_id, category, SUM(value) AS value, MAX(created_at) AS latest_at").
+		Where("game_id IN ? OR category NOT IN ?", summed, baseCategories).
+		Group("game_id, group_id, participant_id, heat_id, category").
+		Scan(&rows)
+
+	scores := make([]models.Score, 0, len(rows))
+	for _, row := range rows {
+		row.Score.CreatedAt = parseTime(row.LatestAt)
+		scores = append(scores, row.Score)
+	}
+
+	if len(picked) > 0 {
+		var base []models.Score
+		query.Session(&gorm.Session{}).
+			Where("game_id IN ? AND category IN ?", picked, baseCategories).
+			Find(&base)
+		scores = append(scores, base...)
+	}
+
+	return append(scores, revised...)
+}
+
+func parseTime(value string) time.Time {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
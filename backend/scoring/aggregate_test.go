@@ -0,0 +1,282 @@
+package scoring
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/scoresystem/backend/config"
+	"github.com/scoresystem/backend/database"
+	"github.com/scoresystem/backend/models"
+)
+
+// openTestDB points the database package at a fresh SQLite file.
+func openTestDB(tb testing.TB) {
+	tb.Helper()
+	config.AppConfig.DatabaseURL = filepath.Join(tb.TempDir(), "scores.db")
+	if err := database.Connect(); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		if db, err := database.DB.DB(); err == nil {
+			db.Close()
+		}
+	})
+}
+
+// seedEvent fills the database with an event whose games cycle through the
+// scoring modes, each holding perGame scores for every group. Scores are a
+// second apart from start, with bonuses, penalties and participant scores
+// mixed in among the base scores.
+func seedEvent(tb testing.TB, groupCount, gameCount, perGame int, start time.Time) models.Event {
+	tb.Helper()
+
+	event := models.Event{
+		Name:        "Seeded",
+		Slug:        fmt.Sprintf("seeded-%d", time.Now().UnixNano()),
+		Tiebreakers: models.StringList{"earliest"},
+	}
+	if err := database.DB.Create(&event).Error; err != nil {
+		tb.Fatal(err)
+	}
+
+	groups := make([]models.Group, groupCount)
+	for i := range groups {
+		groups[i] = models.Group{
+			EventID:   event.ID,
+			Name:      fmt.Sprintf("Group %d", i+1),
+			SortOrder: i,
+			Participants: []models.Participant{
+				{Name: fmt.Sprintf("Player %d.1", i+1)},
+				{Name: fmt.Sprintf("Player %d.2", i+1)},
+			},
+		}
+	}
+	if err := database.DB.Create(&groups).Error; err != nil {
+		tb.Fatal(err)
+	}
+
+	modes := []models.Game{
+		{ScoringMode: ModeIncremental},
+		{ScoringMode: ModeMatch},
+		{ScoringMode: ModeAbsolute},
+		{ScoringMode: ModePlacement, PointsTable: models.IntList{10, 8, 6, 4, 2}, TieMode: TieAverage},
+	}
+	games := make([]models.Game, gameCount)
+	for i := range games {
+		games[i] = modes[i%len(modes)]
+		games[i].EventID = event.ID
+		games[i].Name = fmt.Sprintf("Game %d", i+1)
+		games[i].Weight = 1
+		games[i].SortOrder = i
+	}
+	if err := database.DB.Create(&games).Error; err != nil {
+		tb.Fatal(err)
+	}
+
+	var scores []models.Score
+	at := start
+	for k := 0; k < perGame; k++ {
+		for gi, game := range games {
+			for ri, group := range groups {
+				n := len(scores)
+				s := models.Score{
+					CreatedAt: at,
+					GameID:    game.ID,
+					GroupID:   group.ID,
+					Value:     models.NewDecimal(float64((n*7+gi*3+ri)%20) + 0.5),
+					Category:  CategoryBase,
+				}
+				switch {
+				case n%11 == 0:
+					s.Category = "bonus"
+				case n%13 == 0:
+					s.Category = "penalty"
+					s.Value = -s.Value
+				}
+				if n%3 == 0 {
+					s.ParticipantID = &group.Participants[n%2].ID
+				}
+				if game.ScoringMode == ModePlacement {
+					s.Placement = (n+ri)%5 + 1
+				}
+				s.Current = n%17 == 0
+				scores = append(scores, s)
+				at = at.Add(time.Second)
+			}
+		}
+	}
+	if err := database.DB.CreateInBatches(&scores, 500).Error; err != nil {
+		tb.Fatal(err)
+	}
+
+	return event
+}
+
+// reviseSeeded edits and deletes some of the seeded scores on either side of
+// the given moment, recording revisions the way the handlers do.
+func reviseSeeded(tb testing.TB, event models.Event, at time.Time) {
+	tb.Helper()
+
+	_, games := loadSetup(event)
+	var scores []models.Score
+	database.DB.Where("game_id IN ? AND created_at <= ?", gameIDsOf(games), at).Order("id").Find(&scores)
+
+	for i, s := range scores {
+		when := at.Add(time.Minute)
+		if i%2 == 0 {
+			when = s.CreatedAt.Add(time.Millisecond)
+		}
+		switch i % 5 {
+		case 0:
+			revision := models.ScoreRevision{
+				CreatedAt: when,
+				ScoreID:   s.ID,
+				GroupID:   s.GroupID,
+				HeatID:    s.HeatID,
+				Value:     s.Value,
+				Placement: s.Placement,
+				Category:  s.Category,
+				Current:   s.Current,
+			}
+			if s.ParticipantID != nil {
+				revision.ParticipantID = s.ParticipantID
+			}
+			if err := database.DB.Create(&revision).Error; err != nil {
+				tb.Fatal(err)
+			}
+			updates := map[string]interface{}{"value": s.Value + models.NewDecimal(100), "placement": 1}
+			if i%3 == 0 {
+				updates["category"] = "adjustment"
+			}
+			if err := database.DB.Model(&models.Score{}).Where("id = ?", s.ID).Updates(updates).Error; err != nil {
+				tb.Fatal(err)
+			}
+		case 3:
+			if err := database.DB.Model(&models.Score{}).Where("id = ?", s.ID).Update("deleted_at", when).Error; err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+}
+
+func gameIDsOf(games []models.Game) []uint {
+	ids := make([]uint, len(games))
+	for i, g := range games {
+		ids[i] = g.ID
+	}
+	return ids
+}
+
+func TestSummedScoresMatchRows(t *testing.T) {
+	openTestDB(t)
+
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.Local)
+	event := seedEvent(t, 6, 8, 6, start)
+	asOf := start.Add(100 * time.Second)
+	reviseSeeded(t, event, asOf)
+
+	groups, games := loadSetup(event)
+	ids := gameIDsOf(games)
+
+	var rows []models.Score
+	database.DB.Where("game_id IN ?", ids).Find(&rows)
+	before := start.Add(-time.Second)
+
+	tests := []struct {
+		name   string
+		rows   []models.Score
+		summed []models.Score
+	}{
+		{"live", rows, summedScores(games, nil)},
+		{"as of", scoresAt(ids, asOf), summedScores(games, &asOf)},
+		{"before any score", scoresAt(ids, before), summedScores(games, &before)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.summed) > len(tt.rows) {
+				t.Errorf("%d summed rows for %d scores", len(tt.summed), len(tt.rows))
+			}
+			want := BuildLeaderboard(event, groups, games, tt.rows)
+			got := BuildLeaderboard(event, groups, games, tt.summed)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("leaderboard from summed scores differs from row by row\ngot:  %+v\nwant: %+v", got, want)
+			}
+		})
+	}
+
+	live := BuildLeaderboard(event, groups, games, rows)
+	then := BuildLeaderboard(event, groups, games, scoresAt(ids, asOf))
+	if reflect.DeepEqual(live, then) {
+		t.Error("seeded edits did not change the standings, so the as of case proves nothing")
+	}
+}
+
+func BenchmarkLeaderboard(b *testing.B) {
+	openTestDB(b)
+
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.Local)
+	event := seedEvent(b, 20, 12, 25, start)
+	asOf := start.Add(time.Hour)
+	reviseSeeded(b, event, asOf)
+
+	groups, games := loadSetup(event)
+	ids := gameIDsOf(games)
+
+	b.Run("live/rows", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var scores []models.Score
+			database.DB.Where("game_id IN ?", ids).Find(&scores)
+			BuildLeaderboard(event, groups, games, scores)
+		}
+	})
+	b.Run("live/summed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BuildLeaderboard(event, groups, games, summedScores(games, nil))
+		}
+	})
+	b.Run("as_of/rows", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BuildLeaderboard(event, groups, games, scoresAt(ids, asOf))
+		}
+	})
+	b.Run("as_of/summed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BuildLeaderboard(event, groups, games, summedScores(games, &asOf))
+		}
+	})
+	b.Run("timeline", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BuildTimeline(event, groups, games, loadHistory(games, nil), 0)
+		}
+	})
+}
+
+func TestSummedScoresAreExact(t *testing.T) {
+	openTestDB(t)
+
+	event := models.Event{Name: "Exact", Slug: "exact"}
+	database.DB.Create(&event)
+	group := models.Group{EventID: event.ID, Name: "Group"}
+	database.DB.Create(&group)
+	game := models.Game{EventID: event.ID, Name: "Game", ScoringMode: ModeIncremental, Weight: 1, Decimals: 1}
+	database.DB.Create(&game)
+
+	var scores []models.Score
+	for _, category := range []string{CategoryBase, CategoryBonus} {
+		for _, v := range []float64{0.1, 0.2, 0.3, 0.1, 0.2, 0.1} {
+			scores = append(scores, models.Score{GameID: game.ID, GroupID: group.ID, Value: models.NewDecimal(v), Category: category})
+		}
+	}
+	database.DB.Create(&scores)
+
+	games := []models.Game{game}
+	board := BuildLeaderboard(event, []models.Group{group}, games, summedScores(games, nil))
+	cell := board[0].ScoresByGame[0]
+	if cell.Categories[CategoryBase] != 1 || cell.Categories[CategoryBonus] != 1 || board[0].TotalScore != 2 {
+		t.Errorf("got base %v, bonus %v, total %v; want 1, 1 and 2", cell.Categories[CategoryBase], cell.Categories[CategoryBonus], board[0].TotalScore)
+	}
+}
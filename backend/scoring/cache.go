@@ -0,0 +1,147 @@
+package scoring
+
+import (
+	"sync"
+	"time"
+)
+
+// boardKey identifies a cached leaderboard: an event's live standings, or
+// its standings at the moment they were frozen.
+type boardKey struct {
+	eventID  uint
+	frozenAt int64
+}
+
+func (k boardKey) board() boardKey { return k }
+
+// timelineKey identifies a cached timeline, live or up to the freeze, at one
+// bucket interval.
+type timelineKey struct {
+	boardKey
+	interval time.Duration
+}
+
+// flight is a value being built. Requests that miss the cache while it is
+// running wait for it instead of building the same value again.
+type flight[V any] struct {
+	done  chan struct{}
+	value V
+}
+
+// cacheKey is a key for a value derived from one event's live or frozen
+// standings.
+type cacheKey interface {
+	comparable
+	board() boardKey
+}
+
+// store holds one kind of cached value along with the builds in flight.
+type store[K cacheKey, V any] struct {
+	values   map[K]V
+	building map[K]*flight[V]
+}
+
+func newStore[K cacheKey, V any]() store[K, V] {
+	return store[K, V]{values: make(map[K]V), building: make(map[K]*flight[V])}
+}
+
+// drop forgets every value and build for which match is true.
+func (s store[K, V]) drop(match func(K) bool) {
+	for key := range s.values {
+		if match(key) {
+			delete(s.values, key)
+		}
+	}
+	for key := range s.building {
+		if match(key) {
+			delete(s.building, key)
+		}
+	}
+}
+
+// cache holds each event's live and frozen standings and timelines. Every
+// invalidation bumps the event's version, so a value built while a write was
+// landing is not stored over the invalidation that followed it.
+var cache = struct {
+	sync.Mutex
+	boards    store[boardKey, []LeaderboardEntry]
+	timelines store[timelineKey, []GroupTimeline]
+	versions  map[uint]uint64
+}{
+	boards:    newStore[boardKey, []LeaderboardEntry](),
+	timelines: newStore[timelineKey, []GroupTimeline](),
+	versions:  make(map[uint]uint64),
+}
+
+// InvalidateLeaderboard drops an event's cached standings. Handlers call it
+// once a write that can move the standings has been committed.
+func InvalidateLeaderboard(eventID uint) {
+	cache.Lock()
+	defer cache.Unlock()
+	cache.versions[eventID]++
+	// Builds already running started before the write, so later requests
+	// must not wait for them.
+	cache.boards.drop(func(key boardKey) bool { return key.eventID == eventID })
+	cache.timelines.drop(func(key timelineKey) bool { return key.eventID == eventID })
+}
+
+func liveKey(eventID uint) boardKey {
+	return boardKey{eventID: eventID}
+}
+
+func frozenKey(eventID uint, frozenAt time.Time) boardKey {
+	return boardKey{eventID: eventID, frozenAt: frozenAt.UnixNano()}
+}
+
+func cachedLeaderboard(key boardKey, build func() []LeaderboardEntry) []LeaderboardEntry {
+	board := cached(&cache.boards, key, build)
+	return append([]LeaderboardEntry(nil), board...)
+}
+
+// cachedTimeline returns the shared timeline for the key. Callers may filter
+// the slice but must not modify its points.
+func cachedTimeline(key timelineKey, build func() []GroupTimeline) []GroupTimeline {
+	timelines := cached(&cache.timelines, key, build)
+	return append([]GroupTimeline(nil), timelines...)
+}
+
+func cached[K cacheKey, V any](s *store[K, V], key K, build func() V) V {
+	board := key.board()
+	cache.Lock()
+	if value, ok := s.values[key]; ok {
+		cache.Unlock()
+		return value
+	}
+	if f, ok := s.building[key]; ok {
+		cache.Unlock()
+		<-f.done
+		return f.value
+	}
+	f := &flight[V]{done: make(chan struct{})}
+	s.building[key] = f
+	version := cache.versions[board.eventID]
+	cache.Unlock()
+
+	built := false
+	defer func() {
+		cache.Lock()
+		if s.building[key] == f {
+			delete(s.building, key)
+		}
+		if built && cache.versions[board.eventID] == version {
+			// An event is only frozen at one moment, so values for earlier
+			// freezes are dropped.
+			s.drop(func(k K) bool {
+				other := k.board()
+				return other.eventID == board.eventID && other.frozenAt != board.frozenAt && other.frozenAt != 0
+			})
+			s.values[key] = f.value
+		}
+		cache.Unlock()
+		close(f.done)
+	}()
+
+	f.value = build()
+	built = true
+	return f.value
+}
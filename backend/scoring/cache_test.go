@@ -0,0 +1,99 @@
+package scoring
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachedLeaderboardCoalescesBuilds(t *testing.T) {
+	key := liveKey(9001)
+	defer InvalidateLeaderboard(key.eventID)
+
+	var builds atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	build := func() []LeaderboardEntry {
+		if builds.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return []LeaderboardEntry{{GroupID: 1, Rank: 1}}
+	}
+
+	var wg sync.WaitGroup
+	boards := make([][]LeaderboardEntry, 8)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		boards[0] = cachedLeaderboard(key, build)
+	}()
+	<-started
+	for i := 1; i < len(boards); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			boards[i] = cachedLeaderboard(key, build)
+		}(i)
+	}
+	close(release)
+	wg.Wait()
+
+	if n := builds.Load(); n != 1 {
+		t.Errorf("built %d times, want 1", n)
+	}
+	for i, board := range boards {
+		if len(board) != 1 || board[0].GroupID != 1 {
+			t.Errorf("request %d got %+v", i, board)
+		}
+	}
+}
+
+func TestCachedLeaderboardInvalidatedDuringBuild(t *testing.T) {
+	key := liveKey(9002)
+	defer InvalidateLeaderboard(key.eventID)
+
+	cachedLeaderboard(key, func() []LeaderboardEntry {
+		InvalidateLeaderboard(key.eventID)
+		return []LeaderboardEntry{{GroupID: 1}}
+	})
+
+	board := cachedLeaderboard(key, func() []LeaderboardEntry {
+		return []LeaderboardEntry{{GroupID: 2}}
+	})
+	if len(board) != 1 || board[0].GroupID != 2 {
+		t.Errorf("got %+v, want the board built after the invalidation", board)
+	}
+}
+
+func TestCachedLeaderboardKeepsFrozenBoardPerFreeze(t *testing.T) {
+	const eventID = 9003
+	defer InvalidateLeaderboard(eventID)
+
+	first := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	board := func(id uint) func() []LeaderboardEntry {
+		return func() []LeaderboardEntry { return []LeaderboardEntry{{GroupID: id}} }
+	}
+
+	cachedLeaderboard(liveKey(eventID), board(1))
+	cachedLeaderboard(frozenKey(eventID, first), board(2))
+
+	if got := cachedLeaderboard(frozenKey(eventID, first), board(99)); got[0].GroupID != 2 {
+		t.Errorf("frozen board was rebuilt: %+v", got)
+	}
+	if got := cachedLeaderboard(frozenKey(eventID, second), board(3)); got[0].GroupID != 3 {
+		t.Errorf("board for a new freeze time came from the old one: %+v", got)
+	}
+	if got := cachedLeaderboard(liveKey(eventID), board(99)); got[0].GroupID != 1 {
+		t.Errorf("live board was dropped by a frozen one: %+v", got)
+	}
+
+	cache.Lock()
+	_, kept := cache.boards.values[frozenKey(eventID, first)]
+	cache.Unlock()
+	if kept {
+		t.Error("board for the earlier freeze is still cached")
+	}
+}
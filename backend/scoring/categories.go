@@ -0,0 +1,79 @@
+package scoring
+
+import (
+	"github.com/scoresystem/backend/models"
+)
+
+const (
+	CategoryBase       = "base"
+	CategoryBonus      = "bonus"
+	CategoryPenalty    = "penalty"
+	CategoryAdjustment = "adjustment"
+)
+
+func ValidCategory(category string) bool {
+	switch category {
+	case CategoryBase, CategoryBonus, CategoryPenalty, CategoryAdjustment:
+		return true
+	}
+	return false
+}
+
+func isBase(s models.Score) bool {
+	return s.Category == "" || s.Category == CategoryBase
+}
+
+// NormalizeValue stores penalties as negative values so that every total is
+// a plain sum of its entries.
+func NormalizeValue(category string, value float64) float64 {
+	if category == CategoryPenalty && value > 0 {
+		return -value
+	}
+	return value
+}
+
+// GameBreakdown returns the points each group holds in a single game, split
+// by category. Base scores go through the game's scoring mode and conversion;
+// bonuses, penalties and adjustments are added on top as points.
+func GameBreakdown(game models.Game, scores []models.Score) map[uint]map[string]float64 {
+	breakdown := make(map[uint]map[string]float64)
+	for groupID, points := range ConvertResults(game, GameResults(game, scores)) {
+		addCategory(breakdown, groupID, CategoryBase, points)
+	}
+	for _, s := range scores {
+		if s.GameID == game.ID && !isBase(s) {
+			addCategory(breakdown, s.GroupID, s.Category, s.Value.Float64())
+		}
+	}
+	return breakdown
+}
+
+// ParticipantBreakdown is GameBreakdown for scores awarded to participants.
+func ParticipantBreakdown(game models.Game, scores []models.Score) map[uint]map[string]float64 {
+	breakdown := make(map[uint]map[string]float64)
+	for participantID, points := range ConvertResults(game, ParticipantResults(game, scores)) {
+		addCategory(breakdown, participantID, CategoryBase, points)
+	}
+	for _, s := range scores {
+		if s.GameID == game.ID && !isBase(s) && s.ParticipantID != nil {
+			addCategory(breakdown, *s.ParticipantID, s.Category, s.Value.Float64())
+		}
+	}
+	return breakdown
+}
+
+func addCategory(breakdown map[uint]map[string]float64, id uint, category string, points float64) {
+	if breakdown[id] == nil {
+		breakdown[id] = make(map[string]float64)
+	}
+	// Adding in thousandths keeps totals such as 0.1 + 0.2 exact.
+	breakdown[id][category] = (models.NewDecimal(breakdown[id][category]) + models.NewDecimal(points)).Float64()
+}
+
+func sumCategories(categories map[string]float64) float64 {
+	total := 0.0
+	for _, points := range categories {
+		total += points
+	}
+	return total
+}
@@ -0,0 +1,23 @@
+package scoring
+
+import (
+	"math"
+
+	"github.com/scoresystem/backend/models"
+)
+
+// MaxDecimals is the finest precision a game can record scores with.
+const MaxDecimals = models.DecimalPlaces
+
+// Round rounds a value to the given number of decimal places, half away from
+// zero, so stored scores and computed totals carry a fixed precision.
+func Round(value float64, decimals int) float64 {
+	if decimals < 0 {
+		decimals = 0
+	}
+	if decimals > MaxDecimals {
+		decimals = MaxDecimals
+	}
+	scale := math.Pow10(decimals)
+	return math.Round(value*scale) / scale
+}
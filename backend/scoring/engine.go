@@ -0,0 +1,347 @@
+package scoring
+
+import (
+	"sort"
+
+	"github.com/scoresystem/backend/models"
+)
+
+const (
+	ModeIncremental = "incremental"
+	ModeAbsolute    = "absolute"
+	ModePlacement   = "placement"
+	ModeJudged      = "judged"
+	ModeMatch       = "match"
+)
+
+const (
+	TieAverage = "average"
+	TieEqual   = "equal"
+)
+
+const (
+	MetricPoints   = "points"
+	MetricTime     = "time"
+	MetricDistance = "distance"
+)
+
+const (
+	SortDesc = "desc"
+	SortAsc  = "asc"
+)
+
+const (
+	ConvertPlacement = "placement"
+	ConvertRelative  = "relative"
+)
+
+func ValidMode(mode string) bool {
+	switch mode {
+	case ModeIncremental, ModeAbsolute, ModePlacement, ModeJudged, ModeMatch:
+		return true
+	}
+	return false
+}
+
+func ValidTieMode(mode string) bool {
+	return mode == TieAverage || mode == TieEqual
+}
+
+func ValidMetric(metric string) bool {
+	return metric == MetricPoints || metric == MetricTime || metric == MetricDistance
+}
+
+func ValidSortDirection(direction string) bool {
+	return direction == SortDesc || direction == SortAsc
+}
+
+func ValidConversion(conversion string) bool {
+	return conversion == "" || conversion == ConvertPlacement || conversion == ConvertRelative
+}
+
+// CountedScores returns the scores of a single game that contribute to the
+// standings, with Value holding the points each one is worth. Bonuses,
+// penalties and adjustments always count. For base scores, incremental
+// and match games count every entry; absolute and placement games count
+// only the score marked as current for each group or participant, falling
+// back to the latest one. Judged games keep the latest mark from each judge.
+// Games run in heats apply these rules within each heat and then keep only
+// the heats that count.
+func CountedScores(game models.Game, scores []models.Score) []models.Score {
+	counted := countedScores(game, scores)
+	if game.HeatCounting == HeatBest || game.HeatCounting == HeatFinal {
+		return selectHeats(game, counted)
+	}
+	return counted
+}
+
+func countedScores(game models.Game, scores []models.Score) []models.Score {
+	if game.ScoringMode == ModeIncremental || game.ScoringMode == ModeMatch || game.ScoringMode == "" {
+		counted := make([]models.Score, 0, len(scores))
+		for _, s := range scores {
+			if s.GameID == game.ID {
+				counted = append(counted, s)
+			}
+		}
+		return counted
+	}
+
+	var extras []models.Score
+	chosen := make(map[scoreTarget]models.Score)
+	for _, s := range scores {
+		if s.GameID != game.ID {
+			continue
+		}
+		if !isBase(s) {
+			extras = append(extras, s)
+			continue
+		}
+		target := targetOf(s)
+		if game.ScoringMode == ModeJudged {
+			target.judgeID = s.CreatedBy
+		}
+		prev, ok := chosen[target]
+		if !ok || supersedes(s, prev) {
+			chosen[target] = s
+		}
+	}
+
+	counted := make([]models.Score, 0, len(chosen)+len(extras))
+	for _, s := range chosen {
+		counted = append(counted, s)
+	}
+	sort.Slice(counted, func(i, j int) bool {
+		return counted[i].ID < counted[j].ID
+	})
+
+	if game.ScoringMode == ModePlacement {
+		byHeat := make(map[uint][]int)
+		for i, s := range counted {
+			heat := heatOf(s)
+			byHeat[heat] = append(byHeat[heat], i)
+		}
+		for _, indices := range byHeat {
+			placements := make([]int, len(indices))
+			for i, idx := range indices {
+				placements[i] = counted[idx].Placement
+			}
+			points := PlacementPoints(game.PointsTable, game.TieMode, game.Decimals, placements)
+			for i, idx := range indices {
+				counted[idx].Value = models.NewDecimal(points[i])
+			}
+		}
+	}
+
+	return append(counted, extras...)
+}
+
+type scoreTarget struct {
+	groupID       uint
+	participantID uint
+	judgeID       uint
+	heatID        uint
+}
+
+func targetOf(s models.Score) scoreTarget {
+	target := scoreTarget{groupID: s.GroupID, heatID: heatOf(s)}
+	if s.ParticipantID != nil {
+		target.participantID = *s.ParticipantID
+	}
+	return target
+}
+
+func supersedes(s, prev models.Score) bool {
+	if s.Current != prev.Current {
+		return s.Current
+	}
+	if !s.CreatedAt.Equal(prev.CreatedAt) {
+		return s.CreatedAt.After(prev.CreatedAt)
+	}
+	return s.ID > prev.ID
+}
+
+// PlacementPoints converts finishing positions into points using the game's
+// points table. Groups sharing a position either split the points of the
+// places they occupy or all receive the points of the highest one.
+func PlacementPoints(table []int, tieMode string, decimals int, placements []int) []float64 {
+	tied := make(map[int]int)
+	for _, p := range placements {
+		tied[p]++
+	}
+
+	pointsAt := func(place int) float64 {
+		if place < 1 || place > len(table) {
+			return 0
+		}
+		return float64(table[place-1])
+	}
+
+	points := make([]float64, len(placements))
+	for i, p := range placements {
+		if p < 1 {
+			continue
+		}
+		if tieMode == TieEqual || tied[p] == 1 {
+			points[i] = pointsAt(p)
+			continue
+		}
+		sum := 0.0
+		for place := p; place < p+tied[p]; place++ {
+			sum += pointsAt(place)
+		}
+		points[i] = Round(sum/float64(tied[p]), decimals)
+	}
+	return points
+}
+
+// targetResults returns the raw result recorded for each group-level or
+// participant-level target in each heat, aggregating judges' marks in judged
+// games.
+func targetResults(game models.Game, scores []models.Score) map[scoreTarget]float64 {
+	return aggregateTargets(game, CountedScores(game, scores))
+}
+
+func aggregateTargets(game models.Game, counted []models.Score) map[scoreTarget]float64 {
+	values := make(map[scoreTarget][]models.Decimal)
+	for _, s := range counted {
+		if !isBase(s) {
+			continue
+		}
+		target := targetOf(s)
+		values[target] = append(values[target], s.Value)
+	}
+
+	results := make(map[scoreTarget]float64, len(values))
+	for target, entries := range values {
+		if game.ScoringMode == ModeJudged {
+			marks := make([]float64, len(entries))
+			for i, v := range entries {
+				marks[i] = v.Float64()
+			}
+			results[target] = Aggregate(game.Aggregation, game.Decimals, marks)
+			continue
+		}
+		var sum models.Decimal
+		for _, v := range entries {
+			sum += v
+		}
+		results[target] = Round(sum.Float64(), game.Decimals)
+	}
+	return results
+}
+
+// GameResults returns the raw base result each group recorded in a single
+// game.
+func GameResults(game models.Game, scores []models.Score) map[uint]float64 {
+	results := make(map[uint]float64)
+	for target, v := range targetResults(game, scores) {
+		results[target.groupID] += v
+	}
+	return results
+}
+
+// ParticipantResults returns the raw result each participant recorded in a
+// single game.
+func ParticipantResults(game models.Game, scores []models.Score) map[uint]float64 {
+	results := make(map[uint]float64)
+	for target, v := range targetResults(game, scores) {
+		if target.participantID != 0 {
+			results[target.participantID] += v
+		}
+	}
+	return results
+}
+
+// GameTotals returns the points each group earned in a single game.
+func GameTotals(game models.Game, scores []models.Score) map[uint]float64 {
+	totals := make(map[uint]float64)
+	for groupID, categories := range GameBreakdown(game, scores) {
+		totals[groupID] = Round(sumCategories(categories), game.Decimals)
+	}
+	return totals
+}
+
+// ConvertResults turns raw results keyed by group or participant into
+// leaderboard points. Games without a conversion score their raw results
+// directly; placement conversion ranks the results and awards points from the
+// points table, relative conversion awards MaxPoints scaled by how close each
+// result is to the best one.
+func ConvertResults(game models.Game, results map[uint]float64) map[uint]float64 {
+	if game.Conversion == "" {
+		return results
+	}
+
+	ids := make([]uint, 0, len(results))
+	for id := range results {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return better(game, results[ids[i]], results[ids[j]])
+	})
+
+	points := make(map[uint]float64, len(ids))
+	if len(ids) == 0 {
+		return points
+	}
+
+	switch game.Conversion {
+	case ConvertPlacement:
+		placements := make([]int, len(ids))
+		for i, id := range ids {
+			if i > 0 && sameScore(results[id], results[ids[i-1]]) {
+				placements[i] = placements[i-1]
+			} else {
+				placements[i] = i + 1
+			}
+		}
+		converted := PlacementPoints(game.PointsTable, game.TieMode, game.Decimals, placements)
+		for i, id := range ids {
+			points[id] = converted[i]
+		}
+	case ConvertRelative:
+		best := results[ids[0]]
+		for _, id := range ids {
+			points[id] = relativePoints(game, best, results[id])
+		}
+	}
+	return points
+}
+
+func better(game models.Game, a, b float64) bool {
+	if game.SortDirection == SortAsc {
+		return a < b
+	}
+	return a > b
+}
+
+func relativePoints(game models.Game, best, result float64) float64 {
+	ratio := 0.0
+	if game.SortDirection == SortAsc {
+		if result > 0 {
+			ratio = best / result
+		}
+	} else if best > 0 {
+		ratio = result / best
+	}
+	if ratio < 0 {
+		ratio = 0
+	}
+	return Round(float64(game.MaxPoints)*ratio, game.Decimals)
+}
+
+// MarkCounted flags every score that contributes to the standings so clients
+// can tell superseded entries apart from live ones.
+func MarkCounted(games []models.Game, scores []models.Score) {
+	counted := make(map[uint]models.Score)
+	for _, game := range games {
+		for _, s := range CountedScores(game, scores) {
+			counted[s.ID] = s
+		}
+	}
+	for i := range scores {
+		if s, ok := counted[scores[i].ID]; ok {
+			scores[i].Counted = true
+			scores[i].Value = s.Value
+		}
+	}
+}
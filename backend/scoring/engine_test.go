@@ -0,0 +1,43 @@
+package scoring
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlacementPoints(t *testing.T) {
+	table := []int{10, 8, 6, 4}
+
+	tests := []struct {
+		name       string
+		tieMode    string
+		decimals   int
+		placements []int
+		want       []float64
+	}{
+		{"no ties", TieAverage, 0, []int{1, 2, 3, 4}, []float64{10, 8, 6, 4}},
+		{"order of entries", TieAverage, 0, []int{3, 1, 2}, []float64{6, 10, 8}},
+		{"two tied average", TieAverage, 0, []int{1, 1, 3}, []float64{9, 9, 6}},
+		{"three tied average", TieAverage, 0, []int{2, 2, 2, 1}, []float64{6, 6, 6, 10}},
+		{"tied average rounds", TieAverage, 2, []int{1, 1, 1}, []float64{8, 8, 8}},
+		{"tied average keeps decimals", TieAverage, 1, []int{2, 2, 2, 2}, []float64{4.5, 4.5, 4.5, 4.5}},
+		{"tied average rounds to decimals", TieAverage, 0, []int{2, 2, 2, 2}, []float64{5, 5, 5, 5}},
+		{"two tied equal", TieEqual, 0, []int{1, 1, 3}, []float64{10, 10, 6}},
+		{"three tied equal", TieEqual, 0, []int{2, 2, 2, 1}, []float64{8, 8, 8, 10}},
+		{"past the table", TieAverage, 0, []int{4, 5, 9}, []float64{4, 0, 0}},
+		{"past the table equal", TieEqual, 0, []int{5, 5}, []float64{0, 0}},
+		{"tie running off the table", TieAverage, 0, []int{4, 4}, []float64{2, 2}},
+		{"tie entirely off the table", TieAverage, 0, []int{6, 6}, []float64{0, 0}},
+		{"no placement", TieAverage, 0, []int{0, -1, 1}, []float64{0, 0, 10}},
+		{"empty", TieAverage, 0, []int{}, []float64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PlacementPoints(table, tt.tieMode, tt.decimals, tt.placements)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlacementPoints(%v) = %v, want %v", tt.placements, got, tt.want)
+			}
+		})
+	}
+}
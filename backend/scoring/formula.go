@@ -0,0 +1,372 @@
+package scoring
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode"
+
+	"github.com/scoresystem/backend/models"
+)
+
+const (
+	maxFormulaLength = 256
+	maxFormulaDepth  = 32
+)
+
+// Formula is a compiled arithmetic expression over named inputs. Only numbers,
+// input names, + - * /, parentheses and the functions min, max, abs and round
+// are supported, so admins cannot reach anything outside the inputs given to
+// Eval.
+type Formula struct {
+	root   formulaNode
+	inputs []string
+}
+
+type formulaNode interface {
+	eval(inputs map[string]float64) (float64, error)
+}
+
+type numberNode float64
+
+type inputNode string
+
+type unaryNode struct {
+	operand formulaNode
+}
+
+type binaryNode struct {
+	op          byte
+	left, right formulaNode
+}
+
+type callNode struct {
+	name string
+	args []formulaNode
+}
+
+func CompileFormula(expr string) (*Formula, error) {
+	if len(expr) > maxFormulaLength {
+		return nil, fmt.Errorf("formula is longer than %d characters", maxFormulaLength)
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &formulaParser{tokens: tokens, inputs: make(map[string]bool)}
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+
+	inputs := make([]string, 0, len(p.inputs))
+	for name := range p.inputs {
+		inputs = append(inputs, name)
+	}
+	sort.Strings(inputs)
+
+	return &Formula{root: root, inputs: inputs}, nil
+}
+
+// Inputs returns the names the formula reads, sorted alphabetically.
+func (f *Formula) Inputs() []string {
+	return f.inputs
+}
+
+func (f *Formula) Eval(inputs map[string]float64) (float64, error) {
+	for _, name := range f.inputs {
+		if _, ok := inputs[name]; !ok {
+			return 0, fmt.Errorf("missing input %q", name)
+		}
+	}
+	value, err := f.root.eval(inputs)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, errors.New("formula result is not a finite number")
+	}
+	return value, nil
+}
+
+func (n numberNode) eval(map[string]float64) (float64, error) {
+	return float64(n), nil
+}
+
+func (n inputNode) eval(inputs map[string]float64) (float64, error) {
+	return inputs[string(n)], nil
+}
+
+func (n unaryNode) eval(inputs map[string]float64) (float64, error) {
+	v, err := n.operand.eval(inputs)
+	return -v, err
+}
+
+func (n binaryNode) eval(inputs map[string]float64) (float64, error) {
+	left, err := n.left.eval(inputs)
+	if err != nil {
+		return 0, err
+	}
+	right, err := n.right.eval(inputs)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return left + right, nil
+	case '-':
+		return left - right, nil
+	case '*':
+		return left * right, nil
+	case '/':
+		if right == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return left / right, nil
+	}
+	return 0, fmt.Errorf("unknown operator %q", n.op)
+}
+
+func (n callNode) eval(inputs map[string]float64) (float64, error) {
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(inputs)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	switch n.name {
+	case "abs":
+		return math.Abs(args[0]), nil
+	case "round":
+		return math.Round(args[0]), nil
+	case "min":
+		result := args[0]
+		for _, v := range args[1:] {
+			result = math.Min(result, v)
+		}
+		return result, nil
+	case "max":
+		result := args[0]
+		for _, v := range args[1:] {
+			result = math.Max(result, v)
+		}
+		return result, nil
+	}
+	return 0, fmt.Errorf("unknown function %q", n.name)
+}
+
+// formulaArity lists the supported functions and their minimum and maximum
+// argument counts, where -1 means unbounded.
+var formulaArity = map[string][2]int{
+	"abs":   {1, 1},
+	"round": {1, 1},
+	"min":   {2, -1},
+	"max":   {2, -1},
+}
+
+type formulaToken struct {
+	kind byte // n: number, i: identifier, o: operator or punctuation
+	text string
+}
+
+func tokenize(expr string) ([]formulaToken, error) {
+	var tokens []formulaToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, formulaToken{kind: 'n', text: string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, formulaToken{kind: 'i', text: string(runes[i:j])})
+			i = j
+		case r < unicode.MaxASCII && containsByte("+-*/(),", byte(r)):
+			tokens = append(tokens, formulaToken{kind: 'o', text: string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("formula is empty")
+	}
+	return tokens, nil
+}
+
+func containsByte(set string, b byte) bool {
+	for i := 0; i < len(set); i++ {
+		if set[i] == b {
+			return true
+		}
+	}
+	return false
+}
+
+type formulaParser struct {
+	tokens []formulaToken
+	pos    int
+	inputs map[string]bool
+}
+
+func (p *formulaParser) peek() (formulaToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return formulaToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *formulaParser) accept(op string) bool {
+	if t, ok := p.peek(); ok && t.kind == 'o' && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *formulaParser) parseExpr(depth int) (formulaNode, error) {
+	left, err := p.parseTerm(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op byte
+		if p.accept("+") {
+			op = '+'
+		} else if p.accept("-") {
+			op = '-'
+		} else {
+			return left, nil
+		}
+		right, err := p.parseTerm(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *formulaParser) parseTerm(depth int) (formulaNode, error) {
+	left, err := p.parseFactor(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op byte
+		if p.accept("*") {
+			op = '*'
+		} else if p.accept("/") {
+			op = '/'
+		} else {
+			return left, nil
+		}
+		right, err := p.parseFactor(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *formulaParser) parseFactor(depth int) (formulaNode, error) {
+	if depth > maxFormulaDepth {
+		return nil, errors.New("formula is nested too deeply")
+	}
+
+	if p.accept("-") {
+		operand, err := p.parseFactor(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{operand: operand}, nil
+	}
+
+	if p.accept("(") {
+		inner, err := p.parseExpr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, errors.New("missing closing parenthesis")
+		}
+		return inner, nil
+	}
+
+	t, ok := p.peek()
+	if !ok {
+		return nil, errors.New("unexpected end of formula")
+	}
+	p.pos++
+
+	switch t.kind {
+	case 'n':
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.text)
+		}
+		return numberNode(v), nil
+	case 'i':
+		if !p.accept("(") {
+			p.inputs[t.text] = true
+			return inputNode(t.text), nil
+		}
+		arity, ok := formulaArity[t.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %q", t.text)
+		}
+		var args []formulaNode
+		if !p.accept(")") {
+			for {
+				arg, err := p.parseExpr(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if p.accept(")") {
+					break
+				}
+				if !p.accept(",") {
+					return nil, errors.New("expected , or ) in function call")
+				}
+			}
+		}
+		if len(args) < arity[0] || (arity[1] >= 0 && len(args) > arity[1]) {
+			return nil, fmt.Errorf("wrong number of arguments to %s", t.text)
+		}
+		return callNode{name: t.text, args: args}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+// FormulaValue computes the points of a score in a formula game from the raw
+// inputs recorded with it, rounded to the game's precision.
+func FormulaValue(game models.Game, inputs map[string]float64) (float64, error) {
+	f, err := CompileFormula(game.Formula)
+	if err != nil {
+		return 0, err
+	}
+	value, err := f.Eval(inputs)
+	if err != nil {
+		return 0, err
+	}
+	return Round(value, game.Decimals), nil
+}
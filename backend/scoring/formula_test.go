@@ -0,0 +1,143 @@
+package scoring
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormulaEval(t *testing.T) {
+	inputs := map[string]float64{"time": 90, "penalty": 5, "bonus": 2}
+
+	tests := []struct {
+		name string
+		expr string
+		want float64
+	}{
+		{"number", "42", 42},
+		{"decimal", "1.5", 1.5},
+		{"input", "time", 90},
+		{"multiplication before addition", "1 + 2 * 3", 7},
+		{"division before subtraction", "10 - 6 / 2", 7},
+		{"parentheses", "(1 + 2) * 3", 9},
+		{"left associative subtraction", "10 - 4 - 3", 3},
+		{"left associative division", "64 / 4 / 2", 8},
+		{"unary minus", "-3 + 5", 2},
+		{"unary minus binds to factor", "-2 * -3", 6},
+		{"double negation", "--4", 4},
+		{"inputs", "time - penalty * 2 + bonus", 82},
+		{"abs", "abs(penalty - time)", 85},
+		{"round", "round(2.5)", 3},
+		{"min of two", "min(time, 60)", 60},
+		{"max of many", "max(1, bonus, penalty, 3)", 5},
+		{"nested calls", "max(min(time, 100), round(abs(-0.4)))", 90},
+		{"whitespace", "  1+\t2 ", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := CompileFormula(tt.expr)
+			if err != nil {
+				t.Fatalf("CompileFormula(%q): %v", tt.expr, err)
+			}
+			got, err := f.Eval(inputs)
+			if err != nil {
+				t.Fatalf("Eval(%q): %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileFormulaErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{"empty", "   ", "formula is empty"},
+		{"too long", strings.Repeat("1+", maxFormulaLength/2) + "1", "longer than"},
+		{"bad character", "time ^ 2", "unexpected character"},
+		{"bad number", "1.2.3", "invalid number"},
+		{"trailing operator", "1 +", "unexpected end"},
+		{"trailing token", "1 2", "unexpected"},
+		{"unclosed parenthesis", "(1 + 2", "missing closing parenthesis"},
+		{"unknown function", "sqrt(4)", "unknown function"},
+		{"abs without arguments", "abs()", "wrong number of arguments to abs"},
+		{"abs with two arguments", "abs(1, 2)", "wrong number of arguments to abs"},
+		{"round with two arguments", "round(1, 2)", "wrong number of arguments to round"},
+		{"min with one argument", "min(1)", "wrong number of arguments to min"},
+		{"max without arguments", "max()", "wrong number of arguments to max"},
+		{"missing comma", "max(1 2)", "expected , or )"},
+		{"nested parentheses", strings.Repeat("(", maxFormulaDepth+1) + "1" + strings.Repeat(")", maxFormulaDepth+1), "nested too deeply"},
+		{"nested negation", strings.Repeat("-", maxFormulaDepth+2) + "1", "nested too deeply"},
+		{"nested calls", strings.Repeat("abs(", maxFormulaDepth+1) + "1" + strings.Repeat(")", maxFormulaDepth+1), "nested too deeply"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileFormula(tt.expr)
+			if err == nil {
+				t.Fatalf("CompileFormula(%q) succeeded, want error containing %q", tt.expr, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("CompileFormula(%q) error = %q, want it to contain %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileFormulaDepthLimit(t *testing.T) {
+	expr := strings.Repeat("(", maxFormulaDepth) + "1" + strings.Repeat(")", maxFormulaDepth)
+	f, err := CompileFormula(expr)
+	if err != nil {
+		t.Fatalf("CompileFormula at the depth limit: %v", err)
+	}
+	if got, err := f.Eval(nil); err != nil || got != 1 {
+		t.Errorf("Eval = %v, %v; want 1, nil", got, err)
+	}
+}
+
+func TestFormulaEvalErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		expr   string
+		inputs map[string]float64
+		want   string
+	}{
+		{"division by zero", "1 / 0", nil, "division by zero"},
+		{"division by zero input", "time / penalty", map[string]float64{"time": 1, "penalty": 0}, "division by zero"},
+		{"division by zero expression", "1 / (2 - 2)", nil, "division by zero"},
+		{"division by zero in call", "max(1, 1 / 0)", nil, "division by zero"},
+		{"missing input", "time + penalty", map[string]float64{"time": 1}, `missing input "penalty"`},
+		{"overflow", "x * x", map[string]float64{"x": 1e200}, "not a finite number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := CompileFormula(tt.expr)
+			if err != nil {
+				t.Fatalf("CompileFormula(%q): %v", tt.expr, err)
+			}
+			_, err = f.Eval(tt.inputs)
+			if err == nil {
+				t.Fatalf("Eval(%q) succeeded, want error containing %q", tt.expr, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Eval(%q) error = %q, want it to contain %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormulaInputs(t *testing.T) {
+	f, err := CompileFormula("time + penalty * time - max(bonus, 1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(f.Inputs(), ",")
+	if got != "bonus,penalty,time" {
+		t.Errorf("Inputs() = %s, want bonus,penalty,time", got)
+	}
+}
@@ -0,0 +1,60 @@
+package scoring
+
+import "github.com/scoresystem/backend/models"
+
+const (
+	HeatBest  = "best"
+	HeatSum   = "sum"
+	HeatFinal = "final"
+)
+
+// ValidHeatCounting accepts an empty value for games that are not run in
+// heats.
+func ValidHeatCounting(counting string) bool {
+	return counting == "" || counting == HeatBest || counting == HeatSum || counting == HeatFinal
+}
+
+func heatOf(s models.Score) uint {
+	if s.HeatID == nil {
+		return 0
+	}
+	return *s.HeatID
+}
+
+// selectHeats drops base scores from heats that do not count: everything
+// outside the final heat, or everything but each target's best heat.
+func selectHeats(game models.Game, counted []models.Score) []models.Score {
+	keep := make(map[scoreTarget]bool)
+	if game.HeatCounting == HeatFinal {
+		if game.FinalHeatID != nil {
+			for _, s := range counted {
+				if heatOf(s) == *game.FinalHeatID {
+					keep[targetOf(s)] = true
+				}
+			}
+		}
+	} else {
+		results := aggregateTargets(game, counted)
+		best := make(map[scoreTarget]scoreTarget)
+		for target, v := range results {
+			key := target
+			key.heatID = 0
+			current, ok := best[key]
+			if !ok || better(game, v, results[current]) ||
+				(sameScore(v, results[current]) && target.heatID < current.heatID) {
+				best[key] = target
+			}
+		}
+		for _, target := range best {
+			keep[target] = true
+		}
+	}
+
+	selected := make([]models.Score, 0, len(counted))
+	for _, s := range counted {
+		if !isBase(s) || keep[targetOf(s)] {
+			selected = append(selected, s)
+		}
+	}
+	return selected
+}
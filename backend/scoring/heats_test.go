@@ -0,0 +1,76 @@
+package scoring
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/scoresystem/backend/models"
+)
+
+func TestCountedScoresHeats(t *testing.T) {
+	heat := func(id uint) *uint { return &id }
+	score := func(id, groupID, heatID uint, value float64) models.Score {
+		return models.Score{ID: id, GameID: 1, GroupID: groupID, HeatID: heat(heatID), Category: CategoryBase, Value: models.NewDecimal(value)}
+	}
+	bonus := models.Score{ID: 20, GameID: 1, GroupID: 1, Category: CategoryBonus, Value: models.NewDecimal(1)}
+
+	scores := []models.Score{
+		score(1, 1, 1, 5),
+		score(2, 1, 2, 3),
+		score(3, 1, 2, 4),
+		score(4, 1, 3, 6),
+		score(5, 2, 1, 2),
+		score(6, 2, 2, 1),
+		score(7, 3, 1, 4),
+		score(8, 3, 3, 4),
+		bonus,
+	}
+
+	tests := []struct {
+		name string
+		game models.Game
+		want []uint
+	}{
+		{
+			name: "sum counts every heat",
+			game: models.Game{HeatCounting: HeatSum},
+			want: []uint{1, 2, 3, 4, 5, 6, 7, 8, 20},
+		},
+		{
+			name: "best heat per group, earlier heat on a tie",
+			game: models.Game{HeatCounting: HeatBest},
+			want: []uint{2, 3, 5, 7, 20},
+		},
+		{
+			name: "best heat when lower is better",
+			game: models.Game{HeatCounting: HeatBest, SortDirection: SortAsc},
+			want: []uint{1, 6, 7, 20},
+		},
+		{
+			name: "final heat only",
+			game: models.Game{HeatCounting: HeatFinal, FinalHeatID: heat(3)},
+			want: []uint{4, 8, 20},
+		},
+		{
+			name: "final heat nobody scored in",
+			game: models.Game{HeatCounting: HeatFinal, FinalHeatID: heat(9)},
+			want: []uint{20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.game.ID = 1
+			tt.game.ScoringMode = ModeIncremental
+			var got []uint
+			for _, s := range CountedScores(tt.game, scores) {
+				got = append(got, s.ID)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("counted scores %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package scoring
+
+import (
+	"sort"
+	"time"
+
+	"github.com/scoresystem/backend/database"
+	"github.com/scoresystem/backend/models"
+	"gorm.io/gorm"
+)
+
+// maxTimelinePoints bounds how many leaderboards a timeline rebuilds.
+const maxTimelinePoints = 200
+
+type TimelinePoint struct {
+	At    time.Time `json:"at"`
+	Total float64   `json:"total"`
+	Rank  int       `json:"rank"`
+}
+
+type GroupTimeline struct {
+	GroupID    uint            `json:"group_id"`
+	GroupName  string          `json:"group_name"`
+	GroupColor string          `json:"group_color"`
+	Points     []TimelinePoint `json:"points"`
+}
+
+// EventTimeline replays an event's standings over time, up to asOf when set.
+// Live timelines and those up to the freeze are cached until
+// InvalidateLeaderboard is called for the event.
+func EventTimeline(event models.Event, interval time.Duration, asOf *time.Time) []GroupTimeline {
+	build := func() []GroupTimeline {
+		groups, games := loadSetup(event)
+		return BuildTimeline(event, groups, games, loadHistory(games, asOf), interval)
+	}
+	if asOf == nil {
+		return cachedTimeline(timelineKey{liveKey(event.ID), interval}, build)
+	}
+	if event.FrozenAt != nil && asOf.Equal(*event.FrozenAt) {
+		return cachedTimeline(timelineKey{frozenKey(event.ID, *asOf), interval}, build)
+	}
+	return build()
+}
+
+// scoreChange is a score being entered, edited or deleted. score holds the
+// state it was left in.
+type scoreChange struct {
+	at      time.Time
+	score   models.Score
+	removed bool
+}
+
+// loadHistory loads every change made to the scores of the given games, up
+// to asOf when set, in the order they were made. Replaying the changes up to
+// a moment gives the scores as they stood then.
+func loadHistory(games []models.Game, asOf *time.Time) []scoreChange {
+	ids := make([]uint, len(games))
+	for i, g := range games {
+		ids[i] = g.ID
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := database.DB.Unscoped().Where("game_id IN ?", ids)
+	if asOf != nil {
+		query = query.Where("created_at <= ?", *asOf)
+	}
+	var scores []models.Score
+	query.Order("id").Find(&scores)
+
+	var revisions []models.ScoreRevision
+	database.DB.Joins("JOIN scores ON scores.id = score_revisions.score_id").
+		Where("scores.game_id IN ?", ids).
+		Order("score_revisions.created_at, score_revisions.id").
+		Find(&revisions)
+	revised := make(map[uint][]models.ScoreRevision)
+	for _, r := range revisions {
+		revised[r.ScoreID] = append(revised[r.ScoreID], r)
+	}
+
+	changes := make([]scoreChange, 0, len(scores)+len(revisions))
+	for _, s := range scores {
+		// Each revision holds the state the score was in until that edit,
+		// so the score starts out as its first revision and is left as the
+		// next one, or as it is now, after each edit.
+		states := make([]models.Score, 0, len(revised[s.ID])+1)
+		for _, r := range revised[s.ID] {
+			state := s
+			applyRevision(&state, r)
+			states = append(states, state)
+		}
+		states = append(states, s)
+
+		changes = append(changes, scoreChange{at: s.CreatedAt, score: states[0]})
+		for i, r := range revised[s.ID] {
+			changes = append(changes, scoreChange{at: r.CreatedAt, score: states[i+1]})
+		}
+		if s.DeletedAt.Valid {
+			changes = append(changes, scoreChange{at: s.DeletedAt.Time, score: s, removed: true})
+		}
+	}
+
+	if asOf != nil {
+		kept := changes[:0]
+		for _, change := range changes {
+			if !change.at.After(*asOf) {
+				kept = append(kept, change)
+			}
+		}
+		changes = kept
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].at.Before(changes[j].at)
+	})
+	return changes
+}
+
+// BuildTimeline replays the standings from a score history, ranking the
+// groups after each moment a score was entered, edited or deleted. Only the
+// games that changed are totalled again. With an interval the moments are
+// bucketed so each bucket contributes one point, taken at its last change.
+// Long timelines are thinned out evenly, always keeping the latest point.
+func BuildTimeline(event models.Event, groups []models.Group, games []models.Game, changes []scoreChange, interval time.Duration) []GroupTimeline {
+	times := make([]time.Time, len(changes))
+	for i, change := range changes {
+		times[i] = change.at
+	}
+	moments := timelineMoments(times, interval)
+
+	timelines := make([]GroupTimeline, len(groups))
+	index := make(map[uint]int, len(groups))
+	for i, g := range groups {
+		timelines[i] = GroupTimeline{
+			GroupID:    g.ID,
+			GroupName:  g.Name,
+			GroupColor: g.Color,
+			Points:     []TimelinePoint{},
+		}
+		index[g.ID] = i
+	}
+
+	live := make(map[uint]liveScores, len(games))
+	standings := make(map[uint]gameStanding, len(games))
+	for _, game := range games {
+		live[game.ID] = nil
+		standings[game.ID] = newGameStanding(game, nil)
+	}
+
+	next := 0
+	for _, at := range moments {
+		dirty := make(map[uint]bool)
+		for ; next < len(changes) && !changes[next].at.After(at); next++ {
+			change := changes[next]
+			scores, ok := live[change.score.GameID]
+			if !ok {
+				continue
+			}
+			if change.removed {
+				live[change.score.GameID] = scores.remove(change.score.ID)
+			} else {
+				live[change.score.GameID] = scores.set(change.score)
+			}
+			dirty[change.score.GameID] = true
+		}
+		for _, game := range games {
+			if dirty[game.ID] {
+				standings[game.ID] = newGameStanding(game, live[game.ID])
+			}
+		}
+
+		for _, entry := range rankStandings(event, groups, games, standings, Scope{}) {
+			i := index[entry.GroupID]
+			timelines[i].Points = append(timelines[i].Points, TimelinePoint{
+				At:    at,
+				Total: entry.AdjustedTotal,
+				Rank:  entry.Rank,
+			})
+		}
+	}
+
+	return timelines
+}
+
+// liveScores are the scores of one game standing at a moment of a replay,
+// in the order they were entered.
+type liveScores []models.Score
+
+func (l liveScores) search(id uint) int {
+	return sort.Search(len(l), func(i int) bool { return l[i].ID >= id })
+}
+
+func (l liveScores) set(s models.Score) liveScores {
+	i := l.search(s.ID)
+	if i < len(l) && l[i].ID == s.ID {
+		l[i] = s
+		return l
+	}
+	l = append(l, models.Score{})
+	copy(l[i+1:], l[i:])
+	l[i] = s
+	return l
+}
+
+func (l liveScores) remove(id uint) liveScores {
+	i := l.search(id)
+	if i == len(l) || l[i].ID != id {
+		return l
+	}
+	return append(l[:i], l[i+1:]...)
+}
+
+func timelineMoments(times []time.Time, interval time.Duration) []time.Time {
+	latest := make(map[int64]time.Time)
+	for _, at := range times {
+		key := at.UnixNano()
+		if interval > 0 {
+			key = at.Truncate(interval).UnixNano()
+		}
+		if at.After(latest[key]) {
+			latest[key] = at
+		}
+	}
+
+	moments := make([]time.Time, 0, len(latest))
+	for _, at := range latest {
+		moments = append(moments, at)
+	}
+	sort.Slice(moments, func(i, j int) bool {
+		return moments[i].Before(moments[j])
+	})
+
+	if len(moments) <= maxTimelinePoints {
+		return moments
+	}
+	thinned := make([]time.Time, 0, maxTimelinePoints)
+	step := float64(len(moments)-1) / float64(maxTimelinePoints-1)
+	for i := 0; i < maxTimelinePoints; i++ {
+		thinned = append(thinned, moments[int(float64(i)*step+0.5)])
+	}
+	return thinned
+}
+
+// ExistedAt limits a score query to the scores that existed at the given
+// moment: entered by then and not yet deleted.
+func ExistedAt(query *gorm.DB, at time.Time) *gorm.DB {
+	return query.Unscoped().
+		Where("scores.created_at <= ?", at).
+		Where("scores.deleted_at IS NULL OR scores.deleted_at > ?", at)
+}
+
+// RestoreScores undoes edits made after the given moment, putting each score
+// back the way it stood then. The earliest later revision of a score holds
+// exactly that state.
+func RestoreScores(scores []models.Score, at time.Time) {
+	if len(scores) == 0 {
+		return
+	}
+	index := make(map[uint]int, len(scores))
+	ids := make([]uint, len(scores))
+	for i, s := range scores {
+		index[s.ID] = i
+		ids[i] = s.ID
+	}
+
+	var revisions []models.ScoreRevision
+	database.DB.Where("score_id IN ? AND created_at > ?", ids, at).
+		Order("created_at, id").
+		Find(&revisions)
+
+	restored := make(map[uint]bool)
+	for _, r := range revisions {
+		if restored[r.ScoreID] {
+			continue
+		}
+		restored[r.ScoreID] = true
+		applyRevision(&scores[index[r.ScoreID]], r)
+	}
+}
+
+// applyRevision puts a score back in the state a revision recorded.
+func applyRevision(s *models.Score, r models.ScoreRevision) {
+	s.GroupID = r.GroupID
+	s.ParticipantID = r.ParticipantID
+	s.HeatID = r.HeatID
+	s.Value = r.Value
+	s.Inputs = r.Inputs
+	s.Placement = r.Placement
+	s.Category = r.Category
+	s.Note = r.Note
+	s.Current = r.Current
+	s.Override = r.Override
+}
+
+// scoresAt loads the scores of the given games as they stood at a moment.
+func scoresAt(gameIDs []uint, at time.Time) []models.Score {
+	var scores []models.Score
+	ExistedAt(database.DB.Where("game_id IN ?", gameIDs), at).Find(&scores)
+	RestoreScores(scores, at)
+	return scores
+}
@@ -0,0 +1,78 @@
+package scoring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildTimelineMatchesHistory(t *testing.T) {
+	openTestDB(t)
+
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.Local)
+	event := seedEvent(t, 5, 8, 4, start)
+	asOf := start.Add(80 * time.Second)
+	reviseSeeded(t, event, asOf)
+
+	groups, games := loadSetup(event)
+	ids := gameIDsOf(games)
+	frozen := asOf.Add(30 * time.Second)
+
+	tests := []struct {
+		name     string
+		asOf     *time.Time
+		interval time.Duration
+	}{
+		{"live", nil, 0},
+		{"up to a moment", &frozen, 0},
+		{"bucketed", nil, 20 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timelines := BuildTimeline(event, groups, games, loadHistory(games, tt.asOf), tt.interval)
+			points := len(timelines[0].Points)
+			if points < 2 {
+				t.Fatalf("timeline has %d points", points)
+			}
+			for i := 0; i < points; i++ {
+				at := timelines[0].Points[i].At
+				if tt.asOf != nil && at.After(*tt.asOf) {
+					t.Fatalf("point at %v is after %v", at, *tt.asOf)
+				}
+				want := make(map[uint]LeaderboardEntry)
+				for _, e := range BuildLeaderboard(event, groups, games, scoresAt(ids, at)) {
+					want[e.GroupID] = e
+				}
+				for _, timeline := range timelines {
+					got := timeline.Points[i]
+					w := want[timeline.GroupID]
+					if got.At != at || got.Total != w.AdjustedTotal || got.Rank != w.Rank {
+						t.Fatalf("group %d at %v: got total %v rank %d, want %v rank %d",
+							timeline.GroupID, at, got.Total, got.Rank, w.AdjustedTotal, w.Rank)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEventTimelineCached(t *testing.T) {
+	openTestDB(t)
+
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.Local)
+	event := seedEvent(t, 3, 4, 2, start)
+	first := EventTimeline(event, 0, nil)
+
+	_, games := loadSetup(event)
+	reviseSeeded(t, event, start.Add(time.Hour))
+	if cached := EventTimeline(event, 0, nil); len(cached[0].Points) != len(first[0].Points) {
+		t.Errorf("cached timeline has %d points, want %d", len(cached[0].Points), len(first[0].Points))
+	}
+
+	InvalidateLeaderboard(event.ID)
+	groups, _ := loadSetup(event)
+	want := BuildTimeline(event, groups, games, loadHistory(games, nil), 0)
+	if got := EventTimeline(event, 0, nil); len(got[0].Points) != len(want[0].Points) || len(got[0].Points) == len(first[0].Points) {
+		t.Errorf("timeline after invalidation has %d points, want %d", len(got[0].Points), len(want[0].Points))
+	}
+}
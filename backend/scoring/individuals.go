@@ -0,0 +1,74 @@
+package scoring
+
+import (
+	"sort"
+
+	"github.com/scoresystem/backend/models"
+)
+
+type IndividualEntry struct {
+	Rank            int                `json:"rank"`
+	ParticipantID   uint               `json:"participant_id"`
+	ParticipantName string             `json:"participant_name"`
+	GroupID         uint               `json:"group_id"`
+	GroupName       string             `json:"group_name"`
+	GroupColor      string             `json:"group_color"`
+	TotalScore      float64            `json:"total_score"`
+	WeightedTotal   float64            `json:"weighted_total"`
+	Categories      map[string]float64 `json:"categories"`
+	ScoresByGame    []GameScore        `json:"scores_by_game"`
+}
+
+// BuildIndividualLeaderboard ranks participants by the points awarded to them
+// personally. Scores without a participant only count toward group totals.
+func BuildIndividualLeaderboard(groups []models.Group, participants []models.Participant, games []models.Game, scores []models.Score) []IndividualEntry {
+	cells := make(map[uint]map[uint]gameCell)
+	for _, game := range games {
+		cells[game.ID] = newGameCells(game, ParticipantBreakdown(game, scores), ParticipantResults(game, scores))
+	}
+
+	groupMap := make(map[uint]models.Group)
+	for _, g := range groups {
+		groupMap[g.ID] = g
+	}
+
+	leaderboard := make([]IndividualEntry, 0, len(participants))
+	for _, participant := range participants {
+		group := groupMap[participant.GroupID]
+		entry := IndividualEntry{
+			ParticipantID:   participant.ID,
+			ParticipantName: participant.Name,
+			GroupID:         group.ID,
+			GroupName:       group.Name,
+			GroupColor:      group.Color,
+			Categories:      make(map[string]float64),
+			ScoresByGame:    []GameScore{},
+		}
+
+		for _, game := range games {
+			gs := newGameScore(game, cells[game.ID][participant.ID])
+			entry.ScoresByGame = append(entry.ScoresByGame, gs)
+			entry.TotalScore += gs.Score
+			entry.WeightedTotal += gs.WeightedScore
+			for category, points := range gs.Categories {
+				entry.Categories[category] += points
+			}
+		}
+
+		roundTotals(&entry.TotalScore, &entry.WeightedTotal, entry.Categories)
+		leaderboard = append(leaderboard, entry)
+	}
+
+	sort.SliceStable(leaderboard, func(i, j int) bool {
+		return leaderboard[i].WeightedTotal > leaderboard[j].WeightedTotal
+	})
+	for i := range leaderboard {
+		if i > 0 && sameScore(leaderboard[i].WeightedTotal, leaderboard[i-1].WeightedTotal) {
+			leaderboard[i].Rank = leaderboard[i-1].Rank
+		} else {
+			leaderboard[i].Rank = i + 1
+		}
+	}
+
+	return leaderboard
+}
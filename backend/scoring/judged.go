@@ -0,0 +1,50 @@
+package scoring
+
+import (
+	"sort"
+)
+
+const (
+	AggregateMean        = "mean"
+	AggregateMedian      = "median"
+	AggregateTrimmedMean = "trimmed_mean"
+)
+
+func ValidAggregation(aggregation string) bool {
+	switch aggregation {
+	case AggregateMean, AggregateMedian, AggregateTrimmedMean:
+		return true
+	}
+	return false
+}
+
+// Aggregate combines the marks given by a judges panel into a single result.
+// The trimmed mean drops the highest and lowest mark once at least three
+// judges have scored.
+func Aggregate(aggregation string, decimals int, marks []float64) float64 {
+	if len(marks) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64(nil), marks...)
+	sort.Float64s(sorted)
+
+	switch aggregation {
+	case AggregateMedian:
+		mid := len(sorted) / 2
+		if len(sorted)%2 == 1 {
+			return sorted[mid]
+		}
+		return Round((sorted[mid-1]+sorted[mid])/2, decimals)
+	case AggregateTrimmedMean:
+		if len(sorted) >= 3 {
+			sorted = sorted[1 : len(sorted)-1]
+		}
+	}
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	return Round(sum/float64(len(sorted)), decimals)
+}
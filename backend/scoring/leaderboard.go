@@ -0,0 +1,270 @@
+package scoring
+
+import (
+	"time"
+
+	"github.com/scoresystem/backend/database"
+	"github.com/scoresystem/backend/models"
+)
+
+type LeaderboardEntry struct {
+	Rank          int                `json:"rank"`
+	GroupID       uint               `json:"group_id"`
+	GroupName     string             `json:"group_name"`
+	GroupColor    string             `json:"group_color"`
+	TotalScore    float64            `json:"total_score"`
+	WeightedTotal float64            `json:"weighted_total"`
+	SizeFactor    float64            `json:"size_factor"`
+	Handicap      float64            `json:"handicap"`
+	AdjustedTotal float64            `json:"adjusted_total"`
+	PreviousRank  int                `json:"previous_rank"`
+	RankDelta     int                `json:"rank_delta"`
+	Categories    map[string]float64 `json:"categories"`
+	ScoresByGame  []GameScore        `json:"scores_by_game"`
+}
+
+type GameScore struct {
+	GameID        uint               `json:"game_id"`
+	GameName      string             `json:"game_name"`
+	Result        *float64           `json:"result,omitempty"`
+	Score         float64            `json:"score"`
+	Categories    map[string]float64 `json:"categories,omitempty"`
+	Decimals      int                `json:"decimals"`
+	Weight        float64            `json:"weight"`
+	WeightedScore float64            `json:"weighted_score"`
+}
+
+// gameCell holds what one group or participant earned in one game.
+type gameCell struct {
+	categories map[string]float64
+	result     float64
+	hasResult  bool
+}
+
+func newGameCells(game models.Game, breakdown map[uint]map[string]float64, results map[uint]float64) map[uint]gameCell {
+	cells := make(map[uint]gameCell, len(breakdown))
+	for id, categories := range breakdown {
+		result, ok := results[id]
+		cells[id] = gameCell{
+			categories: categories,
+			result:     result,
+			hasResult:  ok && game.Conversion != "",
+		}
+	}
+	return cells
+}
+
+// newGameScore reports the points held in one game along with the raw
+// result they were converted from, for games that record times or distances.
+func newGameScore(game models.Game, cell gameCell) GameScore {
+	gs := GameScore{
+		GameID:     game.ID,
+		GameName:   game.Name,
+		Score:      Round(sumCategories(cell.categories), game.Decimals),
+		Categories: cell.categories,
+		Decimals:   game.Decimals,
+		Weight:     game.Weight,
+	}
+	gs.WeightedScore = Round(gs.Score*game.Weight, MaxDecimals)
+	if cell.hasResult {
+		result := cell.result
+		gs.Result = &result
+	}
+	return gs
+}
+
+// BuildLeaderboard totals each group's points across the event's games and
+// ranks the groups by their adjusted total: the weighted total, scaled for
+// group size when the event normalizes by size, plus the group's handicap.
+// Groups need their participants loaded for size normalization.
+func BuildLeaderboard(event models.Event, groups []models.Group, games []models.Game, scores []models.Score) []LeaderboardEntry {
+	return BuildScopedLeaderboard(event, groups, games, scores, Scope{})
+}
+
+// BuildScopedLeaderboard is BuildLeaderboard limited to the games and groups
+// in scope. Size factors are still taken against every group in the event so
+// a group's points match the overall board, while handicaps, which belong to
+// the event as a whole, only count when every game does.
+func BuildScopedLeaderboard(event models.Event, groups []models.Group, games []models.Game, scores []models.Score, scope Scope) []LeaderboardEntry {
+	games = scope.games(games)
+	byGame := make(map[uint][]models.Score, len(games))
+	for _, s := range scores {
+		byGame[s.GameID] = append(byGame[s.GameID], s)
+	}
+	standings := make(map[uint]gameStanding, len(games))
+	for _, game := range games {
+		standings[game.ID] = newGameStanding(game, byGame[game.ID])
+	}
+	return rankStandings(event, groups, games, standings, scope)
+}
+
+// gameStanding holds what each group earned in one game and when it last
+// scored there.
+type gameStanding struct {
+	cells     map[uint]gameCell
+	reachedAt map[uint]time.Time
+}
+
+func newGameStanding(game models.Game, scores []models.Score) gameStanding {
+	standing := gameStanding{
+		cells:     newGameCells(game, GameBreakdown(game, scores), GameResults(game, scores)),
+		reachedAt: make(map[uint]time.Time),
+	}
+	for _, s := range CountedScores(game, scores) {
+		if s.CreatedAt.After(standing.reachedAt[s.GroupID]) {
+			standing.reachedAt[s.GroupID] = s.CreatedAt
+		}
+	}
+	return standing
+}
+
+// rankStandings totals the standings of the scoped games for each group in
+// scope and ranks the groups.
+func rankStandings(event models.Event, groups []models.Group, games []models.Game, standings map[uint]gameStanding, scope Scope) []LeaderboardEntry {
+	factors := sizeFactors(event, groups)
+	groups = scope.groups(groups)
+	reachedAt := make(map[uint]time.Time)
+	for _, game := range games {
+		for id, at := range standings[game.ID].reachedAt {
+			if at.After(reachedAt[id]) {
+				reachedAt[id] = at
+			}
+		}
+	}
+
+	leaderboard := make([]LeaderboardEntry, 0, len(groups))
+	for _, group := range groups {
+		entry := LeaderboardEntry{
+			GroupID:      group.ID,
+			GroupName:    group.Name,
+			GroupColor:   group.Color,
+			TotalScore:   0,
+			SizeFactor:   Round(factors[group.ID], MaxDecimals),
+			Categories:   make(map[string]float64),
+			ScoresByGame: []GameScore{},
+		}
+
+		for _, game := range games {
+			gs := newGameScore(game, standings[game.ID].cells[group.ID])
+			entry.ScoresByGame = append(entry.ScoresByGame, gs)
+			entry.TotalScore += gs.Score
+			entry.WeightedTotal += gs.WeightedScore
+			for category, points := range gs.Categories {
+				entry.Categories[category] += points
+			}
+		}
+
+		roundTotals(&entry.TotalScore, &entry.WeightedTotal, entry.Categories)
+		if len(scope.GameIDs) == 0 {
+			entry.Handicap = group.Handicap
+		}
+		entry.AdjustedTotal = Round(entry.WeightedTotal*factors[group.ID]+entry.Handicap, MaxDecimals)
+		leaderboard = append(leaderboard, entry)
+	}
+
+	rankLeaderboard(leaderboard, event, reachedAt)
+
+	return leaderboard
+}
+
+// EventLeaderboard loads an event's groups, games and scores and ranks them.
+// With asOf set, the standings are rebuilt as they stood then. Rank movement
+// is measured against the standings before the most recent score.
+func EventLeaderboard(event models.Event, asOf *time.Time) []LeaderboardEntry {
+	return EventLeaderboardSince(event, asOf, 0)
+}
+
+// EventLeaderboardSince is EventLeaderboard with rank movement measured over
+// the given window before asOf, or before now when asOf is nil. A zero window
+// falls back to the most recent score. The live standings are cached until
+// InvalidateLeaderboard is called for the event.
+func EventLeaderboardSince(event models.Event, asOf *time.Time, window time.Duration) []LeaderboardEntry {
+	return ScopedLeaderboard(event, Scope{}, asOf, window)
+}
+
+// ScopedLeaderboard is EventLeaderboardSince for a single game, a subset of
+// games or a division of groups. Only whole-event boards are cached, live or
+// at the moment the event was frozen.
+func ScopedLeaderboard(event models.Event, scope Scope, asOf *time.Time, window time.Duration) []LeaderboardEntry {
+	if scope.Whole() && window == 0 {
+		if asOf == nil {
+			return cachedLeaderboard(liveKey(event.ID), func() []LeaderboardEntry {
+				return buildEventLeaderboard(event, scope, nil, 0)
+			})
+		}
+		if event.FrozenAt != nil && asOf.Equal(*event.FrozenAt) {
+			return cachedLeaderboard(frozenKey(event.ID, *asOf), func() []LeaderboardEntry {
+				return buildEventLeaderboard(event, scope, asOf, 0)
+			})
+		}
+	}
+	return buildEventLeaderboard(event, scope, asOf, window)
+}
+
+func buildEventLeaderboard(event models.Event, scope Scope, asOf *time.Time, window time.Duration) []LeaderboardEntry {
+	groups, games := loadSetup(event)
+	games = scope.games(games)
+	scores := summedScores(games, asOf)
+	leaderboard := BuildScopedLeaderboard(event, groups, games, scores, scope)
+
+	previous := leaderboard
+	if since, ok := movementCutoff(games, scores, asOf, window); ok {
+		previous = BuildScopedLeaderboard(event, groups, games, summedScores(games, &since), scope)
+	}
+	ApplyMovement(leaderboard, previous)
+
+	return leaderboard
+}
+
+func loadSetup(event models.Event) ([]models.Group, []models.Game) {
+	var groups []models.Group
+	database.DB.Where("event_id = ?", event.ID).Preload("Participants").Order("sort_order").Find(&groups)
+
+	var games []models.Game
+	database.DB.Where("event_id = ?", event.ID).Order("sort_order").Find(&games)
+
+	return groups, games
+}
+
+// GroupGameScore returns the points a group currently holds in one game. All
+// of the game's scores are loaded since converted games rank groups against
+// each other.
+func GroupGameScore(game models.Game, groupID uint) float64 {
+	return GameTotals(game, summedScores([]models.Game{game}, nil))[groupID]
+}
+
+// sizeFactors scales each group's points to the average group size, so a
+// team of three is not outscored just for having fewer hands than a team of
+// five. Empty groups and events without normalization keep a factor of 1.
+func sizeFactors(event models.Event, groups []models.Group) map[uint]float64 {
+	factors := make(map[uint]float64, len(groups))
+	members, counted := 0, 0
+	for _, g := range groups {
+		factors[g.ID] = 1
+		if len(g.Participants) > 0 {
+			members += len(g.Participants)
+			counted++
+		}
+	}
+	if !event.NormalizeSize || counted == 0 {
+		return factors
+	}
+
+	average := float64(members) / float64(counted)
+	for _, g := range groups {
+		if len(g.Participants) > 0 {
+			factors[g.ID] = average / float64(len(g.Participants))
+		}
+	}
+	return factors
+}
+
+// roundTotals strips the floating point noise that builds up when summing
+// decimal scores across games.
+func roundTotals(total, weighted *float64, categories map[string]float64) {
+	*total = Round(*total, MaxDecimals)
+	*weighted = Round(*weighted, MaxDecimals)
+	for category, points := range categories {
+		categories[category] = Round(points, MaxDecimals)
+	}
+}
@@ -0,0 +1,84 @@
+package scoring
+
+import (
+	"errors"
+
+	"github.com/scoresystem/backend/models"
+)
+
+const (
+	OutcomeWin  = "win"
+	OutcomeDraw = "draw"
+	OutcomeLoss = "loss"
+)
+
+// MatchOutcomes decides who won a match. An explicit winner takes precedence;
+// otherwise the best score by the game's sort direction wins and groups
+// sharing the best score draw. Everyone else loses.
+func MatchOutcomes(game models.Game, entries []models.MatchEntry, winnerID *uint, draw bool) (map[uint]string, *uint, error) {
+	outcomes := make(map[uint]string, len(entries))
+
+	if draw {
+		for _, e := range entries {
+			outcomes[e.GroupID] = OutcomeDraw
+		}
+		return outcomes, nil, nil
+	}
+
+	if winnerID != nil {
+		found := false
+		for _, e := range entries {
+			if e.GroupID == *winnerID {
+				outcomes[e.GroupID] = OutcomeWin
+				found = true
+			} else {
+				outcomes[e.GroupID] = OutcomeLoss
+			}
+		}
+		if !found {
+			return nil, nil, errors.New("winner is not part of the match")
+		}
+		return outcomes, winnerID, nil
+	}
+
+	var best *float64
+	for _, e := range entries {
+		if e.Score == nil {
+			return nil, nil, errors.New("every group needs a score when no winner is given")
+		}
+		if best == nil || better(game, *e.Score, *best) {
+			best = e.Score
+		}
+	}
+
+	var leaders []uint
+	for _, e := range entries {
+		if sameScore(*e.Score, *best) {
+			leaders = append(leaders, e.GroupID)
+		}
+	}
+
+	for _, e := range entries {
+		outcomes[e.GroupID] = OutcomeLoss
+	}
+	if len(leaders) == 1 {
+		outcomes[leaders[0]] = OutcomeWin
+		return outcomes, &leaders[0], nil
+	}
+	for _, id := range leaders {
+		outcomes[id] = OutcomeDraw
+	}
+	return outcomes, nil, nil
+}
+
+func OutcomePoints(game models.Game, outcome string) float64 {
+	switch outcome {
+	case OutcomeWin:
+		return Round(game.WinPoints, game.Decimals)
+	case OutcomeDraw:
+		return Round(game.DrawPoints, game.Decimals)
+	case OutcomeLoss:
+		return Round(game.LossPoints, game.Decimals)
+	}
+	return 0
+}
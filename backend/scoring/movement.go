@@ -0,0 +1,110 @@
+package scoring
+
+import (
+	"time"
+
+	"github.com/scoresystem/backend/database"
+	"github.com/scoresystem/backend/models"
+)
+
+// changeSpread is how far apart scores written together, such as both sides
+// of a match result, can be and still count as a single change.
+const changeSpread = time.Second
+
+type RankChange struct {
+	GroupID      uint `json:"group_id"`
+	Rank         int  `json:"rank"`
+	PreviousRank int  `json:"previous_rank"`
+	RankDelta    int  `json:"rank_delta"`
+}
+
+// ApplyMovement fills in each entry's previous rank from an earlier
+// leaderboard. A positive delta means the group moved up. Groups missing
+// from the earlier standings keep their current rank.
+func ApplyMovement(leaderboard, previous []LeaderboardEntry) {
+	before := make(map[uint]int, len(previous))
+	for _, e := range previous {
+		before[e.GroupID] = e.Rank
+	}
+	for i := range leaderboard {
+		e := &leaderboard[i]
+		e.PreviousRank = e.Rank
+		if rank, ok := before[e.GroupID]; ok {
+			e.PreviousRank = rank
+		}
+		e.RankDelta = e.PreviousRank - e.Rank
+	}
+}
+
+// RankChanges trims a leaderboard down to the movement of each group.
+func RankChanges(leaderboard []LeaderboardEntry) []RankChange {
+	changes := make([]RankChange, len(leaderboard))
+	for i, e := range leaderboard {
+		changes[i] = RankChange{
+			GroupID:      e.GroupID,
+			Rank:         e.Rank,
+			PreviousRank: e.PreviousRank,
+			RankDelta:    e.RankDelta,
+		}
+	}
+	return changes
+}
+
+// movementCutoff picks the moment the previous standings are taken at: the
+// start of the window when one is given, otherwise just before the most
+// recent change. Edits and deletions count as changes as well as new scores.
+func movementCutoff(games []models.Game, scores []models.Score, asOf *time.Time, window time.Duration) (time.Time, bool) {
+	if window > 0 {
+		at := time.Now()
+		if asOf != nil {
+			at = *asOf
+		}
+		return at.Add(-window), true
+	}
+
+	latest := latestChange(games, asOf)
+	for _, s := range scores {
+		if s.CreatedAt.After(latest) {
+			latest = s.CreatedAt
+		}
+	}
+	if latest.IsZero() {
+		return time.Time{}, false
+	}
+	return latest.Add(-changeSpread), true
+}
+
+// latestChange returns when a score of the given games was last edited or
+// deleted, up to asOf when set. The scores themselves carry their creation
+// times, so new scores are left to the caller.
+func latestChange(games []models.Game, asOf *time.Time) time.Time {
+	if len(games) == 0 {
+		return time.Time{}
+	}
+	gameIDs := make([]uint, len(games))
+	for i, g := range games {
+		gameIDs[i] = g.ID
+	}
+
+	revised := database.DB.Model(&models.ScoreRevision{}).
+		Select("COALESCE(MAX(score_revisions.created_at), '')").
+		Joins("JOIN scores ON scores.id = score_revisions.score_id").
+		Where("scores.game_id IN ?", gameIDs)
+	deleted := database.DB.Unscoped().Model(&models.Score{}).
+		Select("COALESCE(MAX(deleted_at), '')").
+		Where("game_id IN ? AND deleted_at IS NOT NULL", gameIDs)
+	if asOf != nil {
+		revised = revised.Where("score_revisions.created_at <= ?", *asOf)
+		deleted = deleted.Where("deleted_at <= ?", *asOf)
+	}
+
+	var revisedAt, deletedAt string
+	revised.Scan(&revisedAt)
+	deleted.Scan(&deletedAt)
+
+	latest := parseTime(revisedAt)
+	if t := parseTime(deletedAt); t.After(latest) {
+		latest = t
+	}
+	return latest
+}
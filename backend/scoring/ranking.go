@@ -0,0 +1,176 @@
+package scoring
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/scoresystem/backend/models"
+)
+
+const (
+	TiebreakMostWins   = "most_wins"
+	TiebreakHeadToHead = "head_to_head"
+	TiebreakBestInGame = "best_in_game"
+	TiebreakEarliest   = "earliest"
+)
+
+func ValidTiebreaker(rule string) bool {
+	switch rule {
+	case TiebreakMostWins, TiebreakHeadToHead, TiebreakBestInGame, TiebreakEarliest:
+		return true
+	}
+	return false
+}
+
+// rankLeaderboard orders the entries by adjusted total, resolves ties with the
+// event's tiebreaker rules in order and assigns competition ranks (1, 2, 2, 4)
+// to groups that remain tied after every rule.
+func rankLeaderboard(entries []LeaderboardEntry, event models.Event, reachedAt map[uint]time.Time) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].AdjustedTotal > entries[j].AdjustedTotal
+	})
+
+	r := ranker{
+		rules:     event.Tiebreakers,
+		wins:      gameWins(entries),
+		reachedAt: reachedAt,
+	}
+	if event.TiebreakGameID != nil {
+		r.gameID = *event.TiebreakGameID
+	}
+
+	ordered := make([]LeaderboardEntry, 0, len(entries))
+	position := 1
+	for _, block := range splitBy(entries, func(e LeaderboardEntry) float64 { return e.AdjustedTotal }) {
+		for _, tied := range r.breakTies(block, r.rules) {
+			for _, e := range tied {
+				e.Rank = position
+				ordered = append(ordered, e)
+			}
+			position += len(tied)
+		}
+	}
+	copy(entries, ordered)
+}
+
+type ranker struct {
+	rules     []string
+	gameID    uint
+	wins      map[uint]int
+	reachedAt map[uint]time.Time
+}
+
+func (r ranker) breakTies(block []LeaderboardEntry, rules []string) [][]LeaderboardEntry {
+	if len(block) < 2 || len(rules) == 0 {
+		return [][]LeaderboardEntry{block}
+	}
+
+	key := r.key(rules[0], block)
+	sorted := append([]LeaderboardEntry(nil), block...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return key(sorted[i]) > key(sorted[j])
+	})
+
+	var result [][]LeaderboardEntry
+	for _, sub := range splitBy(sorted, key) {
+		result = append(result, r.breakTies(sub, rules[1:])...)
+	}
+	return result
+}
+
+// key returns a function where a higher value ranks the group higher.
+func (r ranker) key(rule string, block []LeaderboardEntry) func(LeaderboardEntry) float64 {
+	switch rule {
+	case TiebreakMostWins:
+		return func(e LeaderboardEntry) float64 {
+			return float64(r.wins[e.GroupID])
+		}
+	case TiebreakHeadToHead:
+		h2h := headToHead(block)
+		return func(e LeaderboardEntry) float64 {
+			return float64(h2h[e.GroupID])
+		}
+	case TiebreakBestInGame:
+		return func(e LeaderboardEntry) float64 {
+			for _, gs := range e.ScoresByGame {
+				if gs.GameID == r.gameID {
+					return gs.WeightedScore
+				}
+			}
+			return 0
+		}
+	case TiebreakEarliest:
+		return func(e LeaderboardEntry) float64 {
+			reached, ok := r.reachedAt[e.GroupID]
+			if !ok {
+				// Groups that never scored did not reach their total first.
+				return -math.MaxFloat64
+			}
+			return -float64(reached.UnixMicro())
+		}
+	}
+	return func(LeaderboardEntry) float64 { return 0 }
+}
+
+// gameWins counts, per group, the games in which it holds the top score.
+// Games where every group has the same score produce no winner.
+func gameWins(entries []LeaderboardEntry) map[uint]int {
+	wins := make(map[uint]int)
+	if len(entries) == 0 {
+		return wins
+	}
+	for i := range entries[0].ScoresByGame {
+		best, worst := math.Inf(-1), math.Inf(1)
+		for _, e := range entries {
+			best = math.Max(best, e.ScoresByGame[i].WeightedScore)
+			worst = math.Min(worst, e.ScoresByGame[i].WeightedScore)
+		}
+		if sameScore(best, worst) {
+			continue
+		}
+		for _, e := range entries {
+			if sameScore(e.ScoresByGame[i].WeightedScore, best) {
+				wins[e.GroupID]++
+			}
+		}
+	}
+	return wins
+}
+
+// headToHead counts, per group, the games it won against each of the other
+// groups in the block.
+func headToHead(block []LeaderboardEntry) map[uint]int {
+	h2h := make(map[uint]int)
+	for _, a := range block {
+		for _, b := range block {
+			if a.GroupID == b.GroupID {
+				continue
+			}
+			for i := range a.ScoresByGame {
+				if a.ScoresByGame[i].WeightedScore > b.ScoresByGame[i].WeightedScore &&
+					!sameScore(a.ScoresByGame[i].WeightedScore, b.ScoresByGame[i].WeightedScore) {
+					h2h[a.GroupID]++
+				}
+			}
+		}
+	}
+	return h2h
+}
+
+func splitBy(entries []LeaderboardEntry, key func(LeaderboardEntry) float64) [][]LeaderboardEntry {
+	var blocks [][]LeaderboardEntry
+	for i := 0; i < len(entries); {
+		j := i + 1
+		for j < len(entries) && sameScore(key(entries[i]), key(entries[j])) {
+			j++
+		}
+		blocks = append(blocks, entries[i:j])
+		i = j
+	}
+	return blocks
+}
+
+func sameScore(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
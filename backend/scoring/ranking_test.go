@@ -0,0 +1,132 @@
+package scoring
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/scoresystem/backend/models"
+)
+
+// rankEntry builds a leaderboard entry whose total is the sum of its weighted
+// scores in games 1, 2, 3 and so on.
+func rankEntry(groupID uint, scores ...float64) LeaderboardEntry {
+	e := LeaderboardEntry{GroupID: groupID}
+	for i, s := range scores {
+		e.ScoresByGame = append(e.ScoresByGame, GameScore{GameID: uint(i + 1), WeightedScore: s})
+		e.AdjustedTotal += s
+	}
+	return e
+}
+
+func TestRankLeaderboard(t *testing.T) {
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	gameTwo := uint(2)
+
+	tests := []struct {
+		name      string
+		event     models.Event
+		entries   []LeaderboardEntry
+		reachedAt map[uint]time.Time
+		order     []uint
+		ranks     []int
+	}{
+		{
+			name:    "orders by total",
+			entries: []LeaderboardEntry{rankEntry(1, 10), rankEntry(2, 20), rankEntry(3, 15)},
+			order:   []uint{2, 3, 1},
+			ranks:   []int{1, 2, 3},
+		},
+		{
+			name:    "ties share a rank without tiebreakers",
+			entries: []LeaderboardEntry{rankEntry(1, 10), rankEntry(2, 20), rankEntry(3, 20), rankEntry(4, 5)},
+			order:   []uint{2, 3, 1, 4},
+			ranks:   []int{1, 1, 3, 4},
+		},
+		{
+			name:    "most wins",
+			event:   models.Event{Tiebreakers: models.StringList{TiebreakMostWins}},
+			entries: []LeaderboardEntry{rankEntry(1, 0, 0, 10), rankEntry(2, 5, 5, 0), rankEntry(3, 1, 1, 1)},
+			order:   []uint{2, 1, 3},
+			ranks:   []int{1, 2, 3},
+		},
+		{
+			name:    "head to head only counts the tied groups",
+			event:   models.Event{Tiebreakers: models.StringList{TiebreakHeadToHead}},
+			entries: []LeaderboardEntry{rankEntry(1, 6, 1, 3), rankEntry(2, 4, 2, 4), rankEntry(3, 9, 9, 9)},
+			order:   []uint{3, 2, 1},
+			ranks:   []int{1, 2, 3},
+		},
+		{
+			name:    "head to head level",
+			event:   models.Event{Tiebreakers: models.StringList{TiebreakHeadToHead}},
+			entries: []LeaderboardEntry{rankEntry(1, 6, 4), rankEntry(2, 4, 6)},
+			order:   []uint{1, 2},
+			ranks:   []int{1, 1},
+		},
+		{
+			name:    "best in game",
+			event:   models.Event{Tiebreakers: models.StringList{TiebreakBestInGame}, TiebreakGameID: &gameTwo},
+			entries: []LeaderboardEntry{rankEntry(1, 8, 2), rankEntry(2, 3, 7)},
+			order:   []uint{2, 1},
+			ranks:   []int{1, 2},
+		},
+		{
+			name:      "earliest",
+			event:     models.Event{Tiebreakers: models.StringList{TiebreakEarliest}},
+			entries:   []LeaderboardEntry{rankEntry(1, 5), rankEntry(2, 5)},
+			reachedAt: map[uint]time.Time{1: at(2), 2: at(1)},
+			order:     []uint{2, 1},
+			ranks:     []int{1, 2},
+		},
+		{
+			name:      "earliest puts groups that never scored last",
+			event:     models.Event{Tiebreakers: models.StringList{TiebreakEarliest}},
+			entries:   []LeaderboardEntry{rankEntry(1), rankEntry(2), rankEntry(3), rankEntry(4)},
+			reachedAt: map[uint]time.Time{2: at(5), 4: at(1)},
+			order:     []uint{4, 2, 1, 3},
+			ranks:     []int{1, 2, 3, 3},
+		},
+		{
+			name:      "rules apply in order",
+			event:     models.Event{Tiebreakers: models.StringList{TiebreakMostWins, TiebreakEarliest}},
+			entries:   []LeaderboardEntry{rankEntry(1, 10, 0), rankEntry(2, 0, 10), rankEntry(3, 5, 5)},
+			reachedAt: map[uint]time.Time{1: at(2), 2: at(3), 3: at(1)},
+			order:     []uint{1, 2, 3},
+			ranks:     []int{1, 2, 3},
+		},
+		{
+			name:      "first rule decides before the next",
+			event:     models.Event{Tiebreakers: models.StringList{TiebreakEarliest, TiebreakMostWins}},
+			entries:   []LeaderboardEntry{rankEntry(1, 10, 0), rankEntry(2, 0, 10), rankEntry(3, 5, 5)},
+			reachedAt: map[uint]time.Time{1: at(2), 2: at(3), 3: at(1)},
+			order:     []uint{3, 1, 2},
+			ranks:     []int{1, 2, 3},
+		},
+		{
+			name:    "ties left after every rule share a rank",
+			event:   models.Event{Tiebreakers: models.StringList{TiebreakMostWins, TiebreakHeadToHead}},
+			entries: []LeaderboardEntry{rankEntry(1, 5, 5), rankEntry(2, 10, 10), rankEntry(3, 5, 5), rankEntry(4, 1, 0)},
+			order:   []uint{2, 1, 3, 4},
+			ranks:   []int{1, 2, 2, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := append([]LeaderboardEntry(nil), tt.entries...)
+			rankLeaderboard(entries, tt.event, tt.reachedAt)
+
+			order := make([]uint, len(entries))
+			ranks := make([]int, len(entries))
+			for i, e := range entries {
+				order[i] = e.GroupID
+				ranks[i] = e.Rank
+			}
+			if !reflect.DeepEqual(order, tt.order) || !reflect.DeepEqual(ranks, tt.ranks) {
+				t.Errorf("got groups %v ranked %v, want %v ranked %v", order, ranks, tt.order, tt.ranks)
+			}
+		})
+	}
+}
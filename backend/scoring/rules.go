@@ -0,0 +1,51 @@
+package scoring
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/scoresystem/backend/models"
+)
+
+// CheckScore enforces the game's entry limits and group total cap on a score
+// about to be saved. others holds the game's remaining scores so the group's
+// resulting total can be worked out.
+func CheckScore(game models.Game, score models.Score, others []models.Score) error {
+	if isBase(score) {
+		value := score.Value.Float64()
+		if game.DisallowNegative && value < 0 {
+			return fmt.Errorf("negative values are not allowed in %s", game.Name)
+		}
+		if game.MinValue != nil && value < *game.MinValue {
+			return fmt.Errorf("value %s is below the minimum of %s per entry", formatValue(value, game.Decimals), formatValue(*game.MinValue, game.Decimals))
+		}
+		if game.MaxValue != nil && value > *game.MaxValue {
+			return fmt.Errorf("value %s exceeds the maximum of %s per entry", formatValue(value, game.Decimals), formatValue(*game.MaxValue, game.Decimals))
+		}
+	}
+
+	if game.MaxTotal != nil {
+		if score.CreatedAt.IsZero() {
+			score.CreatedAt = time.Now()
+		}
+		scores := make([]models.Score, 0, len(others)+1)
+		for _, s := range others {
+			if score.Current && targetOf(s) == targetOf(score) {
+				s.Current = false
+			}
+			scores = append(scores, s)
+		}
+		scores = append(scores, score)
+		total := GameTotals(game, scores)[score.GroupID]
+		if total > *game.MaxTotal {
+			return fmt.Errorf("group total of %s would exceed the maximum of %s", formatValue(total, game.Decimals), formatValue(*game.MaxTotal, game.Decimals))
+		}
+	}
+
+	return nil
+}
+
+func formatValue(value float64, decimals int) string {
+	return strconv.FormatFloat(value, 'f', decimals, 64)
+}
@@ -0,0 +1,147 @@
+package scoring
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scoresystem/backend/models"
+)
+
+func TestCheckScore(t *testing.T) {
+	limit := func(v float64) *float64 { return &v }
+	score := func(category string, value float64) models.Score {
+		return models.Score{GameID: 1, GroupID: 1, Category: category, Value: models.NewDecimal(value)}
+	}
+	current := func(value float64) models.Score {
+		s := score(CategoryBase, value)
+		s.Current = true
+		return s
+	}
+
+	incremental := models.Game{ID: 1, Name: "Relay", ScoringMode: ModeIncremental, Weight: 1}
+	withLimits := func(change func(*models.Game)) models.Game {
+		game := incremental
+		change(&game)
+		return game
+	}
+
+	tests := []struct {
+		name   string
+		game   models.Game
+		score  models.Score
+		others []models.Score
+		want   string
+	}{
+		{
+			name:  "no limits",
+			game:  incremental,
+			score: score(CategoryBase, -1000),
+		},
+		{
+			name:  "zero is not negative",
+			game:  withLimits(func(g *models.Game) { g.DisallowNegative = true }),
+			score: score(CategoryBase, 0),
+		},
+		{
+			name:  "smallest negative",
+			game:  withLimits(func(g *models.Game) { g.DisallowNegative = true }),
+			score: score(CategoryBase, -0.001),
+			want:  "negative values are not allowed in Relay",
+		},
+		{
+			name:  "penalties may be negative",
+			game:  withLimits(func(g *models.Game) { g.DisallowNegative = true }),
+			score: score(CategoryPenalty, -5),
+		},
+		{
+			name:  "at the minimum",
+			game:  withLimits(func(g *models.Game) { g.MinValue = limit(1.5) }),
+			score: score(CategoryBase, 1.5),
+		},
+		{
+			name:  "just below the minimum",
+			game:  withLimits(func(g *models.Game) { g.MinValue = limit(1.5); g.Decimals = 3 }),
+			score: score(CategoryBase, 1.499),
+			want:  "value 1.499 is below the minimum of 1.500 per entry",
+		},
+		{
+			name:  "at the maximum",
+			game:  withLimits(func(g *models.Game) { g.MaxValue = limit(10) }),
+			score: score(CategoryBase, 10),
+		},
+		{
+			name:  "just above the maximum",
+			game:  withLimits(func(g *models.Game) { g.MaxValue = limit(10); g.Decimals = 3 }),
+			score: score(CategoryBase, 10.001),
+			want:  "value 10.001 exceeds the maximum of 10.000 per entry",
+		},
+		{
+			name:  "decimal sum at the maximum",
+			game:  withLimits(func(g *models.Game) { g.MaxValue = limit(0.3); g.Decimals = 1 }),
+			score: score(CategoryBase, 0.1+0.2),
+		},
+		{
+			name:  "message uses the game's decimals",
+			game:  withLimits(func(g *models.Game) { g.MaxValue = limit(2.5); g.Decimals = 1 }),
+			score: score(CategoryBase, 2.6),
+			want:  "value 2.6 exceeds the maximum of 2.5 per entry",
+		},
+		{
+			name:  "entry limits skip bonuses",
+			game:  withLimits(func(g *models.Game) { g.MinValue = limit(1); g.MaxValue = limit(10) }),
+			score: score(CategoryBonus, 50),
+		},
+		{
+			name:   "reaches the total cap",
+			game:   withLimits(func(g *models.Game) { g.MaxTotal = limit(20) }),
+			score:  score(CategoryBase, 5),
+			others: []models.Score{score(CategoryBase, 10), score(CategoryBase, 5)},
+		},
+		{
+			name:   "just over the total cap",
+			game:   withLimits(func(g *models.Game) { g.MaxTotal = limit(20); g.Decimals = 3 }),
+			score:  score(CategoryBase, 5.001),
+			others: []models.Score{score(CategoryBase, 10), score(CategoryBase, 5)},
+			want:   "group total of 20.001 would exceed the maximum of 20.000",
+		},
+		{
+			name:   "total cap counts bonuses exactly",
+			game:   withLimits(func(g *models.Game) { g.MaxTotal = limit(1); g.Decimals = 1 }),
+			score:  score(CategoryBase, 0.7),
+			others: []models.Score{score(CategoryBonus, 0.1), score(CategoryBonus, 0.2)},
+		},
+		{
+			name:   "total cap ignores other groups",
+			game:   withLimits(func(g *models.Game) { g.MaxTotal = limit(20) }),
+			score:  score(CategoryBase, 20),
+			others: []models.Score{{GameID: 1, GroupID: 2, Category: CategoryBase, Value: models.NewDecimal(20)}},
+		},
+		{
+			name:   "current score replaces the last one under the cap",
+			game:   models.Game{ID: 1, Name: "Throw", ScoringMode: ModeAbsolute, Weight: 1, MaxTotal: limit(20)},
+			score:  current(20),
+			others: []models.Score{current(15)},
+		},
+		{
+			name:   "current score over the cap",
+			game:   models.Game{ID: 1, Name: "Throw", ScoringMode: ModeAbsolute, Weight: 1, MaxTotal: limit(20)},
+			score:  current(21),
+			others: []models.Score{current(15)},
+			want:   "group total of 21 would exceed the maximum of 20",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckScore(tt.game, tt.score, tt.others)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("CheckScore: %v", err)
+			case tt.want != "" && err == nil:
+				t.Errorf("CheckScore accepted the score, want %q", tt.want)
+			case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+				t.Errorf("CheckScore error %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package scoring
+
+import "github.com/scoresystem/backend/models"
+
+// Scope narrows a leaderboard to some of an event's games, some of its
+// groups, or both. The zero Scope covers the whole event.
+type Scope struct {
+	GameIDs  []uint
+	Division string
+}
+
+func (s Scope) Whole() bool {
+	return len(s.GameIDs) == 0 && s.Division == ""
+}
+
+func (s Scope) games(games []models.Game) []models.Game {
+	if len(s.GameIDs) == 0 {
+		return games
+	}
+	wanted := make(map[uint]bool, len(s.GameIDs))
+	for _, id := range s.GameIDs {
+		wanted[id] = true
+	}
+	kept := make([]models.Game, 0, len(s.GameIDs))
+	for _, g := range games {
+		if wanted[g.ID] {
+			kept = append(kept, g)
+		}
+	}
+	return kept
+}
+
+func (s Scope) groups(groups []models.Group) []models.Group {
+	if s.Division == "" {
+		return groups
+	}
+	kept := make([]models.Group, 0, len(groups))
+	for _, g := range groups {
+		if g.Division == s.Division {
+			kept = append(kept, g)
+		}
+	}
+	return kept
+}
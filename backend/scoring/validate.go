@@ -0,0 +1,105 @@
+package scoring
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scoresystem/backend/models"
+)
+
+func ValidateGame(game models.Game) error {
+	if !ValidMode(game.ScoringMode) {
+		return errors.New("invalid scoring mode")
+	}
+	if game.TieMode != "" && !ValidTieMode(game.TieMode) {
+		return errors.New("invalid tie mode")
+	}
+	if game.ScoringMode == ModePlacement && len(game.PointsTable) == 0 {
+		return errors.New("placement games require a points table")
+	}
+	if game.Weight <= 0 {
+		return errors.New("weight must be greater than zero")
+	}
+	if !ValidMetric(game.MetricType) {
+		return errors.New("invalid metric type")
+	}
+	if !ValidSortDirection(game.SortDirection) {
+		return errors.New("invalid sort direction")
+	}
+	if !ValidConversion(game.Conversion) {
+		return errors.New("invalid conversion")
+	}
+	if game.Conversion == "" && (game.MetricType != MetricPoints || game.SortDirection == SortAsc) {
+		return errors.New("time, distance and lower-is-better games require a conversion to points")
+	}
+	if game.Conversion != "" && game.ScoringMode == ModePlacement {
+		return errors.New("placement games already award points")
+	}
+	if game.Conversion == ConvertPlacement && len(game.PointsTable) == 0 {
+		return errors.New("placement conversion requires a points table")
+	}
+	if game.Conversion == ConvertRelative && game.MaxPoints <= 0 {
+		return errors.New("relative conversion requires max points")
+	}
+	if game.Decimals < 0 || game.Decimals > MaxDecimals {
+		return fmt.Errorf("decimals must be between 0 and %d", MaxDecimals)
+	}
+	if game.Formula != "" {
+		if game.ScoringMode == ModePlacement {
+			return errors.New("placement games cannot use a formula")
+		}
+		if _, err := CompileFormula(game.Formula); err != nil {
+			return fmt.Errorf("invalid formula: %v", err)
+		}
+	}
+	if game.MinValue != nil && game.MaxValue != nil && *game.MinValue > *game.MaxValue {
+		return errors.New("minimum value cannot be greater than maximum value")
+	}
+	if game.DisallowNegative && game.MaxValue != nil && *game.MaxValue < 0 {
+		return errors.New("maximum value cannot be negative when negatives are disallowed")
+	}
+	if game.ScoringMode == ModeMatch && game.WinPoints <= game.LossPoints {
+		return errors.New("win points must be greater than loss points")
+	}
+	if !ValidHeatCounting(game.HeatCounting) {
+		return errors.New("invalid heat counting")
+	}
+	if game.HeatCounting == HeatFinal && game.FinalHeatID == nil {
+		return errors.New("counting only the final heat requires a final heat; mark one first")
+	}
+	if game.ScoringMode == ModeMatch && game.HeatCounting != "" {
+		return errors.New("match games cannot be run in heats")
+	}
+	if game.Aggregation != "" && !ValidAggregation(game.Aggregation) {
+		return errors.New("invalid aggregation")
+	}
+	return nil
+}
+
+// ValidateTiebreakers checks an event's tiebreaker rules. The best_in_game
+// rule needs the game it compares.
+func ValidateTiebreakers(rules []string, gameID *uint) error {
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if !ValidTiebreaker(rule) {
+			return errors.New("invalid tiebreaker: " + rule)
+		}
+		if seen[rule] {
+			return errors.New("duplicate tiebreaker: " + rule)
+		}
+		if rule == TiebreakBestInGame && gameID == nil {
+			return errors.New("best_in_game tiebreaker requires a tiebreak game")
+		}
+		seen[rule] = true
+	}
+	return nil
+}
+
+func ValidateCategoryLabels(labels map[string]string) error {
+	for category := range labels {
+		if !ValidCategory(category) {
+			return errors.New("invalid category: " + category)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package tournament
+
+const (
+	BracketSingle = "single"
+	BracketDouble = "double"
+
+	SeedingLeaderboard = "leaderboard"
+	SeedingManual      = "manual"
+
+	StageWinners    = "winners"
+	StageLosers     = "losers"
+	StageGrandFinal = "grand_final"
+)
+
+func ValidBracketType(t string) bool {
+	return t == BracketSingle || t == BracketDouble
+}
+
+func ValidSeeding(s string) bool {
+	return s == SeedingLeaderboard || s == SeedingManual
+}
+
+// Slot is one match in a generated bracket layout. Seeds holds the 1-based
+// seeds placed directly into the match, with 0 marking a bye; later matches
+// are filled by the matches that point at them through WinnerNext and
+// LoserNext, which index into the same layout.
+type Slot struct {
+	Stage      string
+	Round      int
+	Position   int
+	Seeds      []int
+	WinnerNext int
+	LoserNext  int
+}
+
+// MinGroups returns how many groups a bracket type needs.
+func MinGroups(bracketType string) int {
+	if bracketType == BracketDouble {
+		return 3
+	}
+	return 2
+}
+
+// Layout builds the matches of a bracket for n seeded groups. The field is
+// padded to the next power of two and the top seeds receive the byes.
+// Double elimination adds a losers bracket, a grand final between the two
+// bracket winners and a reset match that is only played when the losers
+// bracket winner takes the first final.
+func Layout(bracketType string, n int) []Slot {
+	size := 2
+	rounds := 1
+	for size < n {
+		size *= 2
+		rounds++
+	}
+
+	var slots []Slot
+	add := func(stage string, round, position int) int {
+		slots = append(slots, Slot{Stage: stage, Round: round, Position: position, WinnerNext: -1, LoserNext: -1})
+		return len(slots) - 1
+	}
+
+	winners := make([][]int, rounds+1)
+	for r := 1; r <= rounds; r++ {
+		for p := 0; p < size>>r; p++ {
+			winners[r] = append(winners[r], add(StageWinners, r, p))
+		}
+	}
+
+	order := seedOrder(size)
+	for p, idx := range winners[1] {
+		slots[idx].Seeds = []int{seedOrBye(order[2*p], n), seedOrBye(order[2*p+1], n)}
+	}
+	for r := 1; r < rounds; r++ {
+		for p, idx := range winners[r] {
+			slots[idx].WinnerNext = winners[r+1][p/2]
+		}
+	}
+
+	if bracketType != BracketDouble {
+		return slots
+	}
+
+	lrounds := 2 * (rounds - 1)
+	losers := make([][]int, lrounds+1)
+	for r := 1; r <= lrounds; r++ {
+		for p := 0; p < size>>(2+(r-1)/2); p++ {
+			losers[r] = append(losers[r], add(StageLosers, r, p))
+		}
+	}
+
+	for p, idx := range winners[1] {
+		slots[idx].LoserNext = losers[1][p/2]
+	}
+	// Losers dropping from later winners rounds are fed in reverse on
+	// alternate rounds so groups are less likely to meet again straight away.
+	for r := 2; r <= rounds; r++ {
+		target := losers[2*(r-1)]
+		for p, idx := range winners[r] {
+			q := p
+			if r%2 == 0 {
+				q = len(target) - 1 - p
+			}
+			slots[idx].LoserNext = target[q]
+		}
+	}
+	for r := 1; r < lrounds; r++ {
+		for p, idx := range losers[r] {
+			if r%2 == 1 {
+				slots[idx].WinnerNext = losers[r+1][p]
+			} else {
+				slots[idx].WinnerNext = losers[r+1][p/2]
+			}
+		}
+	}
+
+	final := add(StageGrandFinal, 1, 0)
+	slots[winners[rounds][0]].WinnerNext = final
+	slots[losers[lrounds][0]].WinnerNext = final
+
+	reset := add(StageGrandFinal, 2, 0)
+	slots[final].WinnerNext = reset
+	slots[final].LoserNext = reset
+
+	return slots
+}
+
+// seedOrder places seeds so that the top seeds can only meet in later
+// rounds, e.g. 1 8 4 5 2 7 3 6 for eight.
+func seedOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		m := len(order)*2 + 1
+		next := make([]int, 0, len(order)*2)
+		for _, s := range order {
+			next = append(next, s, m-s)
+		}
+		order = next
+	}
+	return order
+}
+
+func seedOrBye(seed, n int) int {
+	if seed > n {
+		return 0
+	}
+	return seed
+}
@@ -0,0 +1,215 @@
+package tournament
+
+import "testing"
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		bracketType string
+		n           int
+		matches     int
+		byes        int
+	}{
+		{BracketSingle, 2, 1, 0},
+		{BracketSingle, 3, 3, 1},
+		{BracketSingle, 4, 3, 0},
+		{BracketSingle, 5, 7, 3},
+		{BracketSingle, 6, 7, 2},
+		{BracketSingle, 7, 7, 1},
+		{BracketSingle, 8, 7, 0},
+		{BracketSingle, 9, 15, 7},
+		{BracketDouble, 3, 7, 1},
+		{BracketDouble, 4, 7, 0},
+		{BracketDouble, 5, 15, 3},
+		{BracketDouble, 6, 15, 2},
+		{BracketDouble, 7, 15, 1},
+		{BracketDouble, 8, 15, 0},
+		{BracketDouble, 9, 31, 7},
+	}
+
+	for _, tt := range tests {
+		slots := Layout(tt.bracketType, tt.n)
+		if len(slots) != tt.matches {
+			t.Errorf("%s %d: %d matches, want %d", tt.bracketType, tt.n, len(slots), tt.matches)
+			continue
+		}
+
+		seen := make(map[int]bool)
+		byes := 0
+		feeds := make([]int, len(slots))
+		for i, slot := range slots {
+			feeds[i] += len(slot.Seeds)
+			for _, seed := range slot.Seeds {
+				if seed == 0 {
+					byes++
+					continue
+				}
+				if seen[seed] {
+					t.Errorf("%s %d: seed %d placed twice", tt.bracketType, tt.n, seed)
+				}
+				seen[seed] = true
+			}
+			for _, next := range []int{slot.WinnerNext, slot.LoserNext} {
+				if next == -1 {
+					continue
+				}
+				if next <= i || next >= len(slots) {
+					t.Errorf("%s %d: match %d feeds match %d", tt.bracketType, tt.n, i, next)
+					continue
+				}
+				feeds[next]++
+			}
+		}
+		if len(seen) != tt.n {
+			t.Errorf("%s %d: %d seeds placed, want %d", tt.bracketType, tt.n, len(seen), tt.n)
+		}
+		if byes != tt.byes {
+			t.Errorf("%s %d: %d byes, want %d", tt.bracketType, tt.n, byes, tt.byes)
+		}
+		for i, f := range feeds {
+			if f != 2 {
+				t.Errorf("%s %d: match %d is fed by %d, want 2", tt.bracketType, tt.n, i, f)
+			}
+		}
+		for i, slot := range slots[:len(slots)-1] {
+			if slot.WinnerNext == -1 {
+				t.Errorf("%s %d: winner of match %d has nowhere to go", tt.bracketType, tt.n, i)
+			}
+		}
+		if last := slots[len(slots)-1]; last.WinnerNext != -1 || last.LoserNext != -1 {
+			t.Errorf("%s %d: last match feeds another match", tt.bracketType, tt.n)
+		}
+	}
+}
+
+func TestLayoutByesGoToTopSeeds(t *testing.T) {
+	for n := 2; n <= 9; n++ {
+		for _, slot := range Layout(BracketSingle, n) {
+			if len(slot.Seeds) != 2 || (slot.Seeds[0] != 0 && slot.Seeds[1] != 0) {
+				continue
+			}
+			seed := slot.Seeds[0] + slot.Seeds[1]
+			size := 2
+			for size < n {
+				size *= 2
+			}
+			if seed > size-n {
+				t.Errorf("%d groups: seed %d has a bye", n, seed)
+			}
+		}
+	}
+}
+
+func TestLayoutDoubleGrandFinal(t *testing.T) {
+	for n := MinGroups(BracketDouble); n <= 9; n++ {
+		slots := Layout(BracketDouble, n)
+		final := slots[len(slots)-2]
+		reset := slots[len(slots)-1]
+		if final.Stage != StageGrandFinal || final.Round != 1 {
+			t.Fatalf("%d groups: second to last match is %s round %d, want the grand final", n, final.Stage, final.Round)
+		}
+		if reset.Stage != StageGrandFinal || reset.Round != 2 {
+			t.Fatalf("%d groups: last match is %s round %d, want the reset", n, reset.Stage, reset.Round)
+		}
+		if final.WinnerNext != len(slots)-1 || final.LoserNext != len(slots)-1 {
+			t.Errorf("%d groups: both grand finalists should meet again in the reset", n)
+		}
+
+		var fromWinners, fromLosers int
+		for _, slot := range slots {
+			if slot.WinnerNext != len(slots)-2 {
+				continue
+			}
+			switch slot.Stage {
+			case StageWinners:
+				fromWinners++
+			case StageLosers:
+				fromLosers++
+			}
+		}
+		if fromWinners != 1 || fromLosers != 1 {
+			t.Errorf("%d groups: grand final fed by %d winners and %d losers matches, want 1 each", n, fromWinners, fromLosers)
+		}
+	}
+}
+
+// playOut runs a bracket where the better seed wins every match, except the
+// first grand final when upset is set, and returns the champion, each seed's
+// losses and whether the grand final reset was played.
+func playOut(slots []Slot, upset bool) (champion int, losses map[int]int, reset bool) {
+	entries := make([][]int, len(slots))
+	losses = make(map[int]int)
+
+	for i, slot := range slots {
+		for _, seed := range slot.Seeds {
+			if seed > 0 {
+				entries[i] = append(entries[i], seed)
+			}
+		}
+		switch len(entries[i]) {
+		case 0:
+			continue
+		case 1:
+			if slot.WinnerNext == -1 {
+				champion = entries[i][0]
+			} else {
+				entries[slot.WinnerNext] = append(entries[slot.WinnerNext], entries[i][0])
+			}
+			continue
+		}
+
+		winner, loser := entries[i][0], entries[i][1]
+		if loser < winner {
+			winner, loser = loser, winner
+		}
+		final := slot.Stage == StageGrandFinal && slot.Round == 1
+		if final && upset {
+			winner, loser = loser, winner
+		}
+		if slot.Stage == StageGrandFinal && slot.Round == 2 {
+			reset = true
+		}
+		losses[loser]++
+		if slot.WinnerNext == -1 || (final && losses[winner] == 0) {
+			champion = winner
+			continue
+		}
+		if slot.LoserNext != -1 {
+			entries[slot.LoserNext] = append(entries[slot.LoserNext], loser)
+		}
+		entries[slot.WinnerNext] = append(entries[slot.WinnerNext], winner)
+	}
+	return champion, losses, reset
+}
+
+func TestLayoutPlaysOut(t *testing.T) {
+	tests := []struct {
+		bracketType string
+		upset       bool
+		reset       bool
+		losses      int
+	}{
+		{BracketSingle, false, false, 1},
+		{BracketDouble, false, false, 2},
+		{BracketDouble, true, true, 2},
+	}
+
+	for _, tt := range tests {
+		for n := MinGroups(tt.bracketType); n <= 9; n++ {
+			champion, losses, reset := playOut(Layout(tt.bracketType, n), tt.upset)
+			if champion != 1 {
+				t.Errorf("%s %d: champion is seed %d, want 1", tt.bracketType, n, champion)
+			}
+			if reset != tt.reset {
+				t.Errorf("%s %d upset %v: reset played %v, want %v", tt.bracketType, n, tt.upset, reset, tt.reset)
+			}
+			if tt.upset && losses[1] != 1 {
+				t.Errorf("%s %d: seed 1 lost %d times before winning the reset, want 1", tt.bracketType, n, losses[1])
+			}
+			for seed := 2; seed <= n; seed++ {
+				if losses[seed] != tt.losses {
+					t.Errorf("%s %d: seed %d lost %d times, want %d", tt.bracketType, n, seed, losses[seed], tt.losses)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,539 @@
+package tournament
+
+// edge joins vertices i and j with an integer weight.
+type edge struct {
+	i, j   int
+	weight int64
+}
+
+// maxWeightMatching returns a matching of maximum total weight in a general
+// graph using Edmonds' blossom algorithm, in O(n³). mate[v] is the vertex
+// matched to v, or -1. Vertices are numbered from 0; with integer weights
+// every computation stays integral.
+//
+// This follows Joris van Rantwijk's reference implementation: vertices and
+// blossoms carry labels S (1) and T (2) while alternating trees are grown
+// from unmatched vertices, and dual variables are adjusted whenever no tight
+// edge is left to explore.
+func maxWeightMatching(edges []edge) []int {
+	if len(edges) == 0 {
+		return nil
+	}
+
+	nvertex := 0
+	var maxWeight int64
+	for _, e := range edges {
+		if e.i >= nvertex {
+			nvertex = e.i + 1
+		}
+		if e.j >= nvertex {
+			nvertex = e.j + 1
+		}
+		if e.weight > maxWeight {
+			maxWeight = e.weight
+		}
+	}
+	nedge := len(edges)
+
+	// Endpoint p of edge p/2 is vertex endpoint[p]; p^1 is the other end.
+	endpoint := make([]int, 2*nedge)
+	neighbend := make([][]int, nvertex)
+	for k, e := range edges {
+		endpoint[2*k] = e.i
+		endpoint[2*k+1] = e.j
+		neighbend[e.i] = append(neighbend[e.i], 2*k+1)
+		neighbend[e.j] = append(neighbend[e.j], 2*k)
+	}
+
+	mate := make([]int, nvertex)
+	for v := range mate {
+		mate[v] = -1
+	}
+	label := make([]int, 2*nvertex)
+	labelend := make([]int, 2*nvertex)
+	inblossom := make([]int, nvertex)
+	blossomparent := make([]int, 2*nvertex)
+	blossomchilds := make([][]int, 2*nvertex)
+	blossombase := make([]int, 2*nvertex)
+	blossomendps := make([][]int, 2*nvertex)
+	bestedge := make([]int, 2*nvertex)
+	blossombestedges := make([][]int, 2*nvertex)
+	unusedblossoms := make([]int, 0, nvertex)
+	dualvar := make([]int64, 2*nvertex)
+	allowedge := make([]bool, nedge)
+	var queue []int
+
+	for v := 0; v < nvertex; v++ {
+		inblossom[v] = v
+		blossombase[v] = v
+		blossombase[nvertex+v] = -1
+		dualvar[v] = maxWeight
+		unusedblossoms = append(unusedblossoms, nvertex+v)
+	}
+	for b := range blossomparent {
+		labelend[b] = -1
+		blossomparent[b] = -1
+		bestedge[b] = -1
+	}
+
+	slack := func(k int) int64 {
+		e := edges[k]
+		return dualvar[e.i] + dualvar[e.j] - 2*e.weight
+	}
+
+	var blossomLeaves func(b int, leaves []int) []int
+	blossomLeaves = func(b int, leaves []int) []int {
+		if b < nvertex {
+			return append(leaves, b)
+		}
+		for _, t := range blossomchilds[b] {
+			leaves = blossomLeaves(t, leaves)
+		}
+		return leaves
+	}
+
+	// at indexes a blossom's cyclic child list, counting negative positions
+	// back from the end.
+	at := func(list []int, j int) int {
+		return list[((j%len(list))+len(list))%len(list)]
+	}
+
+	indexOf := func(list []int, x int) int {
+		for i, y := range list {
+			if y == x {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var assignLabel func(w, t, p int)
+	assignLabel = func(w, t, p int) {
+		b := inblossom[w]
+		label[w], label[b] = t, t
+		labelend[w], labelend[b] = p, p
+		bestedge[w], bestedge[b] = -1, -1
+		if t == 1 {
+			queue = blossomLeaves(b, queue)
+		} else if t == 2 {
+			base := blossombase[b]
+			assignLabel(endpoint[mate[base]], 1, mate[base]^1)
+		}
+	}
+
+	// scanBlossom traces back from v and w to find either a new blossom's
+	// base or, with -1, an augmenting path.
+	scanBlossom := func(v, w int) int {
+		var path []int
+		base := -1
+		for v != -1 || w != -1 {
+			b := inblossom[v]
+			if label[b]&4 != 0 {
+				base = blossombase[b]
+				break
+			}
+			path = append(path, b)
+			label[b] = 5
+			if labelend[b] == -1 {
+				v = -1
+			} else {
+				v = endpoint[labelend[b]]
+				b = inblossom[v]
+				v = endpoint[labelend[b]]
+			}
+			if w != -1 {
+				v, w = w, v
+			}
+		}
+		for _, b := range path {
+			label[b] = 1
+		}
+		return base
+	}
+
+	addBlossom := func(base, k int) {
+		v, w := edges[k].i, edges[k].j
+		bb := inblossom[base]
+		bv := inblossom[v]
+		bw := inblossom[w]
+
+		b := unusedblossoms[len(unusedblossoms)-1]
+		unusedblossoms = unusedblossoms[:len(unusedblossoms)-1]
+		blossombase[b] = base
+		blossomparent[b] = -1
+		blossomparent[bb] = b
+
+		var path, endps []int
+		for bv != bb {
+			blossomparent[bv] = b
+			path = append(path, bv)
+			endps = append(endps, labelend[bv])
+			v = endpoint[labelend[bv]]
+			bv = inblossom[v]
+		}
+		path = append(path, bb)
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+		for i, j := 0, len(endps)-1; i < j; i, j = i+1, j-1 {
+			endps[i], endps[j] = endps[j], endps[i]
+		}
+		endps = append(endps, 2*k)
+		for bw != bb {
+			blossomparent[bw] = b
+			path = append(path, bw)
+			endps = append(endps, labelend[bw]^1)
+			w = endpoint[labelend[bw]]
+			bw = inblossom[w]
+		}
+		blossomchilds[b] = path
+		blossomendps[b] = endps
+
+		label[b] = 1
+		labelend[b] = labelend[bb]
+		dualvar[b] = 0
+		for _, v := range blossomLeaves(b, nil) {
+			if label[inblossom[v]] == 2 {
+				queue = append(queue, v)
+			}
+			inblossom[v] = b
+		}
+
+		bestedgeto := make([]int, 2*nvertex)
+		for i := range bestedgeto {
+			bestedgeto[i] = -1
+		}
+		for _, bv := range path {
+			var nblists [][]int
+			if blossombestedges[bv] == nil {
+				for _, v := range blossomLeaves(bv, nil) {
+					nblist := make([]int, len(neighbend[v]))
+					for i, p := range neighbend[v] {
+						nblist[i] = p / 2
+					}
+					nblists = append(nblists, nblist)
+				}
+			} else {
+				nblists = [][]int{blossombestedges[bv]}
+			}
+			for _, nblist := range nblists {
+				for _, k := range nblist {
+					j := edges[k].j
+					if inblossom[j] == b {
+						j = edges[k].i
+					}
+					bj := inblossom[j]
+					if bj != b && label[bj] == 1 && (bestedgeto[bj] == -1 || slack(k) < slack(bestedgeto[bj])) {
+						bestedgeto[bj] = k
+					}
+				}
+			}
+			blossombestedges[bv] = nil
+			bestedge[bv] = -1
+		}
+		best := []int{}
+		for _, k := range bestedgeto {
+			if k != -1 {
+				best = append(best, k)
+			}
+		}
+		blossombestedges[b] = best
+		bestedge[b] = -1
+		for _, k := range best {
+			if bestedge[b] == -1 || slack(k) < slack(bestedge[b]) {
+				bestedge[b] = k
+			}
+		}
+	}
+
+	var expandBlossom func(b int, endstage bool)
+	expandBlossom = func(b int, endstage bool) {
+		for _, s := range blossomchilds[b] {
+			blossomparent[s] = -1
+			if s < nvertex {
+				inblossom[s] = s
+			} else if endstage && dualvar[s] == 0 {
+				expandBlossom(s, endstage)
+			} else {
+				for _, v := range blossomLeaves(s, nil) {
+					inblossom[v] = s
+				}
+			}
+		}
+
+		if !endstage && label[b] == 2 {
+			childs, endps := blossomchilds[b], blossomendps[b]
+			entrychild := inblossom[endpoint[labelend[b]^1]]
+			j := indexOf(childs, entrychild)
+			var jstep, endptrick int
+			if j&1 != 0 {
+				j -= len(childs)
+				jstep, endptrick = 1, 0
+			} else {
+				jstep, endptrick = -1, 1
+			}
+			p := labelend[b]
+			for j != 0 {
+				label[endpoint[p^1]] = 0
+				label[endpoint[at(endps, j-endptrick)^endptrick^1]] = 0
+				assignLabel(endpoint[p^1], 2, p)
+				allowedge[at(endps, j-endptrick)/2] = true
+				j += jstep
+				p = at(endps, j-endptrick) ^ endptrick
+				allowedge[p/2] = true
+				j += jstep
+			}
+			bv := at(childs, j)
+			label[endpoint[p^1]], label[bv] = 2, 2
+			labelend[endpoint[p^1]], labelend[bv] = p, p
+			bestedge[bv] = -1
+			j += jstep
+			for at(childs, j) != entrychild {
+				bv := at(childs, j)
+				if label[bv] == 1 {
+					j += jstep
+					continue
+				}
+				reached := -1
+				for _, v := range blossomLeaves(bv, nil) {
+					if label[v] != 0 {
+						reached = v
+						break
+					}
+				}
+				if reached != -1 {
+					label[reached] = 0
+					label[endpoint[mate[blossombase[bv]]]] = 0
+					assignLabel(reached, 2, labelend[reached])
+				}
+				j += jstep
+			}
+		}
+
+		label[b], labelend[b] = -1, -1
+		blossomchilds[b], blossomendps[b] = nil, nil
+		blossombase[b] = -1
+		blossombestedges[b] = nil
+		bestedge[b] = -1
+		unusedblossoms = append(unusedblossoms, b)
+	}
+
+	var augmentBlossom func(b, v int)
+	augmentBlossom = func(b, v int) {
+		t := v
+		for blossomparent[t] != b {
+			t = blossomparent[t]
+		}
+		if t >= nvertex {
+			augmentBlossom(t, v)
+		}
+		childs, endps := blossomchilds[b], blossomendps[b]
+		i := indexOf(childs, t)
+		j := i
+		var jstep, endptrick int
+		if i&1 != 0 {
+			j -= len(childs)
+			jstep, endptrick = 1, 0
+		} else {
+			jstep, endptrick = -1, 1
+		}
+		for j != 0 {
+			j += jstep
+			t = at(childs, j)
+			p := at(endps, j-endptrick) ^ endptrick
+			if t >= nvertex {
+				augmentBlossom(t, endpoint[p])
+			}
+			j += jstep
+			t = at(childs, j)
+			if t >= nvertex {
+				augmentBlossom(t, endpoint[p^1])
+			}
+			mate[endpoint[p]] = p ^ 1
+			mate[endpoint[p^1]] = p
+		}
+		blossomchilds[b] = append(append([]int(nil), childs[i:]...), childs[:i]...)
+		blossomendps[b] = append(append([]int(nil), endps[i:]...), endps[:i]...)
+		blossombase[b] = blossombase[blossomchilds[b][0]]
+	}
+
+	augmentMatching := func(k int) {
+		for _, start := range [2][2]int{{edges[k].i, 2*k + 1}, {edges[k].j, 2 * k}} {
+			s, p := start[0], start[1]
+			for {
+				bs := inblossom[s]
+				if bs >= nvertex {
+					augmentBlossom(bs, s)
+				}
+				mate[s] = p
+				if labelend[bs] == -1 {
+					break
+				}
+				t := endpoint[labelend[bs]]
+				bt := inblossom[t]
+				s = endpoint[labelend[bt]]
+				j := endpoint[labelend[bt]^1]
+				if bt >= nvertex {
+					augmentBlossom(bt, j)
+				}
+				mate[j] = labelend[bt]
+				p = labelend[bt] ^ 1
+			}
+		}
+	}
+
+	for stage := 0; stage < nvertex; stage++ {
+		for i := range label {
+			label[i] = 0
+			bestedge[i] = -1
+		}
+		for b := nvertex; b < 2*nvertex; b++ {
+			blossombestedges[b] = nil
+		}
+		for k := range allowedge {
+			allowedge[k] = false
+		}
+		queue = queue[:0]
+
+		for v := 0; v < nvertex; v++ {
+			if mate[v] == -1 && label[inblossom[v]] == 0 {
+				assignLabel(v, 1, -1)
+			}
+		}
+
+		augmented := false
+		for {
+			for len(queue) > 0 && !augmented {
+				v := queue[len(queue)-1]
+				queue = queue[:len(queue)-1]
+
+				for _, p := range neighbend[v] {
+					k := p / 2
+					w := endpoint[p]
+					if inblossom[v] == inblossom[w] {
+						continue
+					}
+					var kslack int64
+					if !allowedge[k] {
+						kslack = slack(k)
+						if kslack <= 0 {
+							allowedge[k] = true
+						}
+					}
+					if allowedge[k] {
+						if label[inblossom[w]] == 0 {
+							assignLabel(w, 2, p^1)
+						} else if label[inblossom[w]] == 1 {
+							base := scanBlossom(v, w)
+							if base >= 0 {
+								addBlossom(base, k)
+							} else {
+								augmentMatching(k)
+								augmented = true
+								break
+							}
+						} else if label[w] == 0 {
+							label[w] = 2
+							labelend[w] = p ^ 1
+						}
+					} else if label[inblossom[w]] == 1 {
+						b := inblossom[v]
+						if bestedge[b] == -1 || kslack < slack(bestedge[b]) {
+							bestedge[b] = k
+						}
+					} else if label[w] == 0 {
+						if bestedge[w] == -1 || kslack < slack(bestedge[w]) {
+							bestedge[w] = k
+						}
+					}
+				}
+			}
+			if augmented {
+				break
+			}
+
+			// No tight edge is left, so move the duals by the largest step
+			// that keeps them feasible.
+			deltatype := 1
+			delta := dualvar[0]
+			for v := 1; v < nvertex; v++ {
+				if dualvar[v] < delta {
+					delta = dualvar[v]
+				}
+			}
+			deltaedge, deltablossom := -1, -1
+			for v := 0; v < nvertex; v++ {
+				if label[inblossom[v]] == 0 && bestedge[v] != -1 {
+					if d := slack(bestedge[v]); d < delta {
+						delta, deltatype, deltaedge = d, 2, bestedge[v]
+					}
+				}
+			}
+			for b := 0; b < 2*nvertex; b++ {
+				if blossomparent[b] == -1 && label[b] == 1 && bestedge[b] != -1 {
+					if d := slack(bestedge[b]) / 2; d < delta {
+						delta, deltatype, deltaedge = d, 3, bestedge[b]
+					}
+				}
+			}
+			for b := nvertex; b < 2*nvertex; b++ {
+				if blossombase[b] >= 0 && blossomparent[b] == -1 && label[b] == 2 && dualvar[b] < delta {
+					delta, deltatype, deltablossom = dualvar[b], 4, b
+				}
+			}
+
+			for v := 0; v < nvertex; v++ {
+				switch label[inblossom[v]] {
+				case 1:
+					dualvar[v] -= delta
+				case 2:
+					dualvar[v] += delta
+				}
+			}
+			for b := nvertex; b < 2*nvertex; b++ {
+				if blossombase[b] >= 0 && blossomparent[b] == -1 {
+					switch label[b] {
+					case 1:
+						dualvar[b] += delta
+					case 2:
+						dualvar[b] -= delta
+					}
+				}
+			}
+
+			if deltatype == 1 {
+				break
+			}
+			switch deltatype {
+			case 2:
+				allowedge[deltaedge] = true
+				i := edges[deltaedge].i
+				if label[inblossom[i]] == 0 {
+					i = edges[deltaedge].j
+				}
+				queue = append(queue, i)
+			case 3:
+				allowedge[deltaedge] = true
+				queue = append(queue, edges[deltaedge].i)
+			case 4:
+				expandBlossom(deltablossom, false)
+			}
+		}
+
+		if !augmented {
+			break
+		}
+		for b := nvertex; b < 2*nvertex; b++ {
+			if blossomparent[b] == -1 && blossombase[b] >= 0 && label[b] == 1 && dualvar[b] == 0 {
+				expandBlossom(b, true)
+			}
+		}
+	}
+
+	for v := range mate {
+		if mate[v] >= 0 {
+			mate[v] = endpoint[mate[v]]
+		}
+	}
+	return mate
+}
@@ -0,0 +1,74 @@
+package tournament
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// bestMatching tries every matching of a small graph and returns the
+// heaviest total weight.
+func bestMatching(n int, weights map[[2]int]int64, matched []bool) int64 {
+	v := 0
+	for v < n && matched[v] {
+		v++
+	}
+	if v == n {
+		return 0
+	}
+	matched[v] = true
+	best := bestMatching(n, weights, matched)
+	for w := v + 1; w < n; w++ {
+		weight, ok := weights[[2]int{v, w}]
+		if !ok || matched[w] {
+			continue
+		}
+		matched[w] = true
+		best = max(best, weight+bestMatching(n, weights, matched))
+		matched[w] = false
+	}
+	matched[v] = false
+	return best
+}
+
+func TestMaxWeightMatching(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 1000; trial++ {
+		n := 2 + rng.Intn(11)
+		weights := make(map[[2]int]int64)
+		var edges []edge
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				if rng.Intn(3) == 0 {
+					continue
+				}
+				w := int64(1 + rng.Intn(20))
+				weights[[2]int{i, j}] = w
+				edges = append(edges, edge{i: i, j: j, weight: w})
+			}
+		}
+		if len(edges) == 0 {
+			continue
+		}
+
+		mate := maxWeightMatching(edges)
+		var total int64
+		for v, w := range mate {
+			if w == -1 {
+				continue
+			}
+			if mate[w] != v {
+				t.Fatalf("trial %d: %d is matched to %d but %d to %d", trial, v, w, w, mate[w])
+			}
+			if v < w {
+				weight, ok := weights[[2]int{v, w}]
+				if !ok {
+					t.Fatalf("trial %d: %d and %d are matched without an edge", trial, v, w)
+				}
+				total += weight
+			}
+		}
+		if want := bestMatching(len(mate), weights, make([]bool, len(mate))); total != want {
+			t.Errorf("trial %d: matching weighs %d, want %d", trial, total, want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package tournament
+
+import (
+	"github.com/scoresystem/backend/models"
+	"gorm.io/gorm"
+)
+
+// Advance moves the winner and losers of a decided bracket match into the
+// matches it feeds, then settles any byes that opens up. A winner with
+// nowhere left to go is the bracket's champion.
+func Advance(tx *gorm.DB, match models.Match) error {
+	if err := promote(tx, match); err != nil {
+		return err
+	}
+	return Settle(tx, *match.BracketID)
+}
+
+// Withdraw undoes Advance for a match whose result is about to be replaced.
+func Withdraw(tx *gorm.DB, match models.Match) error {
+	if decidesReset(match) {
+		if err := tx.Model(&models.Match{}).Where("id = ?", *match.WinnerNextID).Updates(map[string]interface{}{
+			"status":    "pending",
+			"winner_id": nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Bracket{}).Where("id = ?", *match.BracketID).Updates(map[string]interface{}{
+			"status":      "active",
+			"champion_id": nil,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	groupIDs := make([]uint, len(match.Entries))
+	for i, e := range match.Entries {
+		groupIDs[i] = e.GroupID
+	}
+	for _, next := range []*uint{match.WinnerNextID, match.LoserNextID} {
+		if next == nil || len(groupIDs) == 0 {
+			continue
+		}
+		if err := tx.Where("match_id = ? AND group_id IN ?", *next, groupIDs).Delete(&models.MatchEntry{}).Error; err != nil {
+			return err
+		}
+	}
+	if match.WinnerNextID == nil {
+		return tx.Model(&models.Bracket{}).Where("id = ?", *match.BracketID).Updates(map[string]interface{}{
+			"status":      "active",
+			"champion_id": nil,
+		}).Error
+	}
+	return nil
+}
+
+// Locked reports whether a match fed by this one has already been decided,
+// in which case its result can no longer change. Matches closed without a
+// winner, such as an unneeded grand final reset, do not count.
+func Locked(tx *gorm.DB, match models.Match) bool {
+	var ids []uint
+	for _, next := range []*uint{match.WinnerNextID, match.LoserNextID} {
+		if next != nil {
+			ids = append(ids, *next)
+		}
+	}
+	if len(ids) == 0 {
+		return false
+	}
+	var count int64
+	tx.Model(&models.Match{}).Where("id IN ? AND status = ? AND winner_id IS NOT NULL", ids, "completed").Count(&count)
+	return count > 0
+}
+
+// Settle completes matches whose feeders are all decided but that ended up
+// with fewer than two groups. A lone group advances on a bye; an empty
+// match is closed without a winner.
+func Settle(tx *gorm.DB, bracketID uint) error {
+	for {
+		var matches []models.Match
+		if err := tx.Where("bracket_id = ?", bracketID).Preload("Entries").Find(&matches).Error; err != nil {
+			return err
+		}
+
+		open := make(map[uint]bool)
+		for _, m := range matches {
+			if m.Status == "completed" {
+				continue
+			}
+			for _, next := range []*uint{m.WinnerNextID, m.LoserNextID} {
+				if next != nil {
+					open[*next] = true
+				}
+			}
+		}
+
+		settled := false
+		for _, m := range matches {
+			if m.Status == "completed" || open[m.ID] || len(m.Entries) >= 2 {
+				continue
+			}
+			updates := map[string]interface{}{"status": "completed"}
+			if len(m.Entries) == 1 {
+				winner := m.Entries[0].GroupID
+				updates["winner_id"] = winner
+				m.WinnerID = &winner
+				if err := tx.Model(&m.Entries[0]).Update("outcome", "win").Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&m).Updates(updates).Error; err != nil {
+				return err
+			}
+			if err := promote(tx, m); err != nil {
+				return err
+			}
+			settled = true
+		}
+
+		if !settled {
+			return nil
+		}
+	}
+}
+
+func promote(tx *gorm.DB, match models.Match) error {
+	if match.WinnerID == nil {
+		return nil
+	}
+
+	if decidesReset(match) {
+		var champion models.Match
+		err := tx.Where("winner_next_id = ? AND stage = ?", match.ID, StageWinners).First(&champion).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		// The winners bracket champion has not lost yet, so beating the
+		// losers bracket winner ends the bracket and the reset is skipped.
+		if champion.WinnerID != nil && *champion.WinnerID == *match.WinnerID {
+			if err := tx.Model(&models.Match{}).Where("id = ?", *match.WinnerNextID).Update("status", "completed").Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.Bracket{}).Where("id = ?", *match.BracketID).Updates(map[string]interface{}{
+				"status":      "completed",
+				"champion_id": *match.WinnerID,
+			}).Error
+		}
+	}
+
+	for _, e := range match.Entries {
+		next := match.LoserNextID
+		if e.GroupID == *match.WinnerID {
+			next = match.WinnerNextID
+		}
+		if next == nil {
+			continue
+		}
+		entry := models.MatchEntry{MatchID: *next, GroupID: e.GroupID, Seed: e.Seed}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+
+	if match.WinnerNextID == nil {
+		return tx.Model(&models.Bracket{}).Where("id = ?", *match.BracketID).Updates(map[string]interface{}{
+			"status":      "completed",
+			"champion_id": *match.WinnerID,
+		}).Error
+	}
+	return nil
+}
+
+// decidesReset reports whether the match is the first grand final of a
+// double elimination bracket, whose result decides if the reset is played.
+func decidesReset(match models.Match) bool {
+	return match.Stage == StageGrandFinal && match.Round == 1 && match.WinnerNextID != nil
+}
+
+// FinishSchedule marks a schedule completed once all of its rounds have
+// been generated and every match in them is decided.
+func FinishSchedule(tx *gorm.DB, scheduleID uint) error {
+	var schedule models.Schedule
+	if err := tx.First(&schedule, scheduleID).Error; err != nil {
+		return err
+	}
+
+	var played int
+	tx.Model(&models.Match{}).Select("COALESCE(MAX(round), 0)").Where("schedule_id = ?", schedule.ID).Scan(&played)
+
+	var open int64
+	tx.Model(&models.Match{}).Where("schedule_id = ? AND status <> ?", schedule.ID, "completed").Count(&open)
+
+	status := "active"
+	if played >= schedule.TotalRounds && open == 0 {
+		status = "completed"
+	}
+	if status == schedule.Status {
+		return nil
+	}
+	return tx.Model(&schedule).Update("status", status).Error
+}
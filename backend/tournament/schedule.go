@@ -0,0 +1,147 @@
+package tournament
+
+import "sort"
+
+const (
+	FormatRoundRobin = "round_robin"
+	FormatSwiss      = "swiss"
+)
+
+func ValidFormat(f string) bool {
+	return f == FormatRoundRobin || f == FormatSwiss
+}
+
+// Pairing is one match in a generated round. Away is 0 when Home sits the
+// round out on a bye.
+type Pairing struct {
+	Home uint
+	Away uint
+}
+
+// RoundRobin pairs every group with every other group exactly once using
+// the circle method. With an odd number of groups each round has one bye.
+func RoundRobin(groupIDs []uint) [][]Pairing {
+	ids := append([]uint(nil), groupIDs...)
+	if len(ids)%2 == 1 {
+		ids = append(ids, 0)
+	}
+	n := len(ids)
+	if n < 2 {
+		return nil
+	}
+
+	rounds := make([][]Pairing, 0, n-1)
+	for r := 0; r < n-1; r++ {
+		round := make([]Pairing, 0, n/2)
+		for i := 0; i < n/2; i++ {
+			home, away := ids[i], ids[n-1-i]
+			if home == 0 {
+				home, away = away, home
+			}
+			round = append(round, Pairing{Home: home, Away: away})
+		}
+		sortByes(round)
+		rounds = append(rounds, round)
+
+		// Keep the first group fixed and rotate the rest one place.
+		last := ids[n-1]
+		copy(ids[2:], ids[1:n-1])
+		ids[1] = last
+	}
+	return rounds
+}
+
+// SwissRounds suggests how many Swiss rounds separate a single leader out
+// of n groups.
+func SwissRounds(n int) int {
+	rounds := 0
+	for size := 1; size < n; size *= 2 {
+		rounds++
+	}
+	return rounds
+}
+
+// SwissPairings pairs groups already ordered by standing so that each meets
+// a close-ranked opponent it has not played. With an odd number of groups
+// the lowest-ranked group without a bye sits out. The round is the pairing
+// with the fewest rematches, then the smallest squared gaps in standing
+// between opponents, with higher-ranked groups getting the closer opponents
+// when that still leaves a choice.
+func SwissPairings(standings []uint, played map[[2]uint]bool, hadBye map[uint]bool) []Pairing {
+	ids := append([]uint(nil), standings...)
+
+	var round []Pairing
+	if len(ids)%2 == 1 {
+		bye := len(ids) - 1
+		for i := len(ids) - 1; i >= 0; i-- {
+			if !hadBye[ids[i]] {
+				bye = i
+				break
+			}
+		}
+		round = append(round, Pairing{Home: ids[bye]})
+		ids = append(ids[:bye], ids[bye+1:]...)
+	}
+
+	return append(pairSwiss(ids, played), round...)
+}
+
+// pairSwiss pairs an even number of groups in standing order as a minimum
+// cost perfect matching. Each cost is a rematch, then the squared gap in
+// standing, then a tiebreak favouring the higher-ranked group, weighted so
+// each part outweighs every possible sum of the parts after it.
+func pairSwiss(ids []uint, played map[[2]uint]bool) []Pairing {
+	n := int64(len(ids))
+	if n == 0 {
+		return nil
+	}
+
+	gapWeight := n * n * n
+	rematchWeight := gapWeight * n * n * n
+	cost := func(i, j int) int64 {
+		gap := int64(j - i)
+		c := gapWeight*gap*gap + (n-int64(i))*gap
+		if played[PairKey(ids[i], ids[j])] {
+			c += rematchWeight
+		}
+		return c
+	}
+
+	// Every weight is positive, so the heaviest matching on the complete
+	// graph pairs every group.
+	var most int64
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			most = max(most, cost(i, j))
+		}
+	}
+	edges := make([]edge, 0, len(ids)*(len(ids)-1)/2)
+	for i := range ids {
+		for j := i + 1; j < len(ids); j++ {
+			edges = append(edges, edge{i: i, j: j, weight: most + 1 - cost(i, j)})
+		}
+	}
+
+	mate := maxWeightMatching(edges)
+	pairs := make([]Pairing, 0, len(ids)/2)
+	for i, j := range mate {
+		if j > i {
+			pairs = append(pairs, Pairing{Home: ids[i], Away: ids[j]})
+		}
+	}
+	return pairs
+}
+
+// PairKey identifies a pair of groups regardless of order.
+func PairKey(a, b uint) [2]uint {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]uint{a, b}
+}
+
+func sortByes(round []Pairing) {
+	sort.SliceStable(round, func(i, j int) bool {
+		return round[i].Away != 0 && round[j].Away == 0
+	})
+}
@@ -0,0 +1,229 @@
+package tournament
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRoundRobin(t *testing.T) {
+	for n := 2; n <= 9; n++ {
+		ids := make([]uint, n)
+		for i := range ids {
+			ids[i] = uint(i + 1)
+		}
+		rounds := RoundRobin(ids)
+
+		wantRounds := n - 1
+		if n%2 == 1 {
+			wantRounds = n
+		}
+		if len(rounds) != wantRounds {
+			t.Errorf("%d groups: %d rounds, want %d", n, len(rounds), wantRounds)
+			continue
+		}
+
+		met := make(map[[2]uint]int)
+		byes := make(map[uint]int)
+		for r, round := range rounds {
+			seen := make(map[uint]bool)
+			for i, p := range round {
+				for _, id := range []uint{p.Home, p.Away} {
+					if id == 0 {
+						continue
+					}
+					if seen[id] {
+						t.Errorf("%d groups: group %d plays twice in round %d", n, id, r+1)
+					}
+					seen[id] = true
+				}
+				if p.Home == 0 {
+					t.Errorf("%d groups: round %d has a pairing without a home group", n, r+1)
+				}
+				if p.Away == 0 {
+					byes[p.Home]++
+					if i != len(round)-1 {
+						t.Errorf("%d groups: bye is not last in round %d", n, r+1)
+					}
+					continue
+				}
+				met[PairKey(p.Home, p.Away)]++
+			}
+			if len(seen) != n {
+				t.Errorf("%d groups: round %d includes %d groups", n, r+1, len(seen))
+			}
+		}
+
+		for a := 1; a <= n; a++ {
+			for b := a + 1; b <= n; b++ {
+				if got := met[PairKey(uint(a), uint(b))]; got != 1 {
+					t.Errorf("%d groups: %d and %d meet %d times, want 1", n, a, b, got)
+				}
+			}
+			want := 0
+			if n%2 == 1 {
+				want = 1
+			}
+			if byes[uint(a)] != want {
+				t.Errorf("%d groups: group %d has %d byes, want %d", n, a, byes[uint(a)], want)
+			}
+		}
+	}
+}
+
+func TestSwissPairings(t *testing.T) {
+	played := func(pairs ...[2]uint) map[[2]uint]bool {
+		m := make(map[[2]uint]bool)
+		for _, p := range pairs {
+			m[PairKey(p[0], p[1])] = true
+		}
+		return m
+	}
+
+	tests := []struct {
+		name      string
+		standings []uint
+		played    map[[2]uint]bool
+		hadBye    map[uint]bool
+		want      []Pairing
+	}{
+		{
+			name:      "first round",
+			standings: []uint{1, 2, 3, 4},
+			want:      []Pairing{{1, 2}, {3, 4}},
+		},
+		{
+			name:      "avoids a rematch",
+			standings: []uint{1, 2, 3, 4},
+			played:    played([2]uint{1, 2}),
+			want:      []Pairing{{1, 3}, {2, 4}},
+		},
+		{
+			name:      "looks ahead to avoid a rematch lower down",
+			standings: []uint{1, 2, 3, 4},
+			played:    played([2]uint{1, 2}, [2]uint{2, 4}),
+			want:      []Pairing{{1, 4}, {2, 3}},
+		},
+		{
+			name:      "bye to the lowest group",
+			standings: []uint{5, 4, 3, 2, 1},
+			want:      []Pairing{{5, 4}, {3, 2}, {1, 0}},
+		},
+		{
+			name:      "bye skips groups that had one",
+			standings: []uint{5, 4, 3, 2, 1},
+			hadBye:    map[uint]bool{1: true, 2: true},
+			want:      []Pairing{{5, 4}, {2, 1}, {3, 0}},
+		},
+		{
+			name:      "bye falls to the lowest group when everyone had one",
+			standings: []uint{3, 2, 1},
+			hadBye:    map[uint]bool{1: true, 2: true, 3: true},
+			want:      []Pairing{{3, 2}, {1, 0}},
+		},
+		{
+			name:      "fewest rematches when one is unavoidable",
+			standings: []uint{1, 2, 3, 4},
+			played:    played([2]uint{1, 2}, [2]uint{1, 3}, [2]uint{1, 4}, [2]uint{3, 4}),
+			want:      []Pairing{{1, 3}, {2, 4}},
+		},
+		{
+			name:      "fewest rematches across a larger field",
+			standings: []uint{1, 2, 3, 4, 5, 6},
+			played: played(
+				[2]uint{1, 2}, [2]uint{1, 3}, [2]uint{1, 4}, [2]uint{1, 5}, [2]uint{1, 6},
+				[2]uint{2, 3}, [2]uint{3, 4},
+			),
+			want: []Pairing{{1, 2}, {3, 5}, {4, 6}},
+		},
+		{
+			name:      "everyone has met",
+			standings: []uint{1, 2, 3, 4},
+			played:    played([2]uint{1, 2}, [2]uint{1, 3}, [2]uint{1, 4}, [2]uint{2, 3}, [2]uint{2, 4}, [2]uint{3, 4}),
+			want:      []Pairing{{1, 2}, {3, 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SwissPairings(tt.standings, tt.played, tt.hadBye)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SwissPairings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwissRounds(t *testing.T) {
+	tests := map[int]int{1: 0, 2: 1, 3: 2, 4: 2, 5: 3, 8: 3, 9: 4, 16: 4, 17: 5}
+	for n, want := range tests {
+		if got := SwissRounds(n); got != want {
+			t.Errorf("SwissRounds(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestSwissPairingsLargeField(t *testing.T) {
+	for _, n := range []int{40, 41} {
+		rng := rand.New(rand.NewSource(int64(n)))
+		points := make(map[uint]int)
+		played := make(map[[2]uint]bool)
+		hadBye := make(map[uint]bool)
+		standings := make([]uint, n)
+		for i := range standings {
+			standings[i] = uint(i + 1)
+		}
+
+		start := time.Now()
+		for round := 1; round < n; round++ {
+			sort.SliceStable(standings, func(i, j int) bool {
+				return points[standings[i]] > points[standings[j]]
+			})
+
+			pairings := SwissPairings(standings, played, hadBye)
+			seen := make(map[uint]bool)
+			rematches := 0
+			for _, p := range pairings {
+				for _, id := range []uint{p.Home, p.Away} {
+					if id == 0 {
+						continue
+					}
+					if seen[id] {
+						t.Fatalf("%d groups: group %d plays twice in round %d", n, id, round)
+					}
+					seen[id] = true
+				}
+				if p.Away == 0 {
+					if hadBye[p.Home] {
+						t.Errorf("%d groups: group %d has a second bye in round %d", n, p.Home, round)
+					}
+					hadBye[p.Home] = true
+					points[p.Home] += 2
+					continue
+				}
+				if played[PairKey(p.Home, p.Away)] {
+					rematches++
+				}
+				played[PairKey(p.Home, p.Away)] = true
+				if rng.Intn(2) == 0 {
+					points[p.Home] += 2
+				} else {
+					points[p.Away] += 2
+				}
+			}
+			if len(seen) != n {
+				t.Fatalf("%d groups: round %d pairs %d groups", n, round, len(seen))
+			}
+			// While every group has yet to meet at least half the field,
+			// a round without rematches always exists.
+			if round <= n/2 && rematches > 0 {
+				t.Errorf("%d groups: %d rematches in round %d", n, rematches, round)
+			}
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("%d groups: %d rounds took %v", n, n-1, elapsed)
+		}
+	}
+}
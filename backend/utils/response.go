@@ -43,3 +43,7 @@ func NotFound(c *gin.Context, message string) {
 func InternalError(c *gin.Context, message string) {
 	ErrorResponse(c, 500, message)
 }
+
+func Conflict(c *gin.Context, message string) {
+	ErrorResponse(c, 409, message)
+}
@@ -2,12 +2,16 @@ package websocket
 
 import (
 	"encoding/json"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/scoresystem/backend/database"
+	"github.com/scoresystem/backend/middleware"
 	"github.com/scoresystem/backend/models"
+	"github.com/scoresystem/backend/scoring"
 )
 
 type MessageType string
@@ -15,21 +19,55 @@ type MessageType string
 const (
 	MessageTypeScoreUpdate MessageType = "score_update"
 	MessageTypeScoreDelete MessageType = "score_delete"
+
+	MessageTypeLeaderboardFreeze MessageType = "leaderboard_freeze"
+	MessageTypeLeaderboardReveal MessageType = "leaderboard_reveal"
+	MessageTypeRevealStart       MessageType = "reveal_start"
+	MessageTypeRevealNext        MessageType = "reveal_next"
+
+	MessageTypeEventUpdate       MessageType = "event_update"
+	MessageTypeEventDelete       MessageType = "event_delete"
+	MessageTypeGroupCreate       MessageType = "group_create"
+	MessageTypeGroupUpdate       MessageType = "group_update"
+	MessageTypeGroupDelete       MessageType = "group_delete"
+	MessageTypeParticipantCreate MessageType = "participant_create"
+	MessageTypeParticipantUpdate MessageType = "participant_update"
+	MessageTypeParticipantDelete MessageType = "participant_delete"
+	MessageTypeGameCreate        MessageType = "game_create"
+	MessageTypeGameUpdate        MessageType = "game_update"
+	MessageTypeGameDelete        MessageType = "game_delete"
 )
 
 type Message struct {
 	Type MessageType    `json:"type"`
 	Data MessagePayload `json:"data"`
+
+	// adminOnly keeps the message from anonymous viewers while the event's
+	// standings are frozen.
+	adminOnly bool
 }
 
 type MessagePayload struct {
-	ScoreID uint `json:"score_id,omitempty"`
-	EventID uint `json:"event_id,omitempty"`
+	ScoreID       uint                       `json:"score_id,omitempty"`
+	EventID       uint                       `json:"event_id,omitempty"`
+	GameID        uint                       `json:"game_id,omitempty"`
+	GroupID       uint                       `json:"group_id,omitempty"`
+	ParticipantID uint                       `json:"participant_id,omitempty"`
+	GameScore     *float64                   `json:"game_score,omitempty"`
+	FrozenAt      *time.Time                 `json:"frozen_at,omitempty"`
+	Leaderboard   any                        `json:"leaderboard,omitempty"`
+	Entry         any                        `json:"entry,omitempty"`
+	Remaining     *int                       `json:"remaining,omitempty"`
+	Ranks         []scoring.RankChange       `json:"ranks,omitempty"`
+	Score         *models.Score              `json:"score,omitempty"`
+	Changed       []scoring.LeaderboardEntry `json:"changed,omitempty"`
+	Entity        any                        `json:"entity,omitempty"`
 }
 
 type Client struct {
 	conn    *websocket.Conn
 	eventID uint
+	admin   bool
 	send    chan []byte
 }
 
@@ -39,6 +77,11 @@ type Hub struct {
 	register   chan *Client
 	unregister chan *Client
 	broadcast  chan Message
+
+	// boards holds the standings last sent for each event, so score
+	// messages only need to carry the entries that changed since.
+	boards    map[uint][]scoring.LeaderboardEntry
+	boardsMux sync.Mutex
 }
 
 var hub *Hub
@@ -49,6 +92,7 @@ func InitHub() {
 		register:   make(chan *Client, 256),
 		unregister: make(chan *Client, 256),
 		broadcast:  make(chan Message, 256),
+		boards:     make(map[uint][]scoring.LeaderboardEntry),
 	}
 	go hub.run()
 }
@@ -89,6 +133,9 @@ func (h *Hub) run() {
 					continue
 				}
 				for client := range clients {
+					if message.adminOnly && !client.admin {
+						continue
+					}
 					select {
 					case client.send <- data:
 					default:
@@ -111,30 +158,215 @@ func (h *Hub) Unregister(client *Client) {
 	h.unregister <- client
 }
 
-func BroadcastScoreUpdate(eventID uint, score models.Score) {
+func BroadcastScoreUpdate(eventID uint, score models.Score, gameScore float64) {
+	if hub != nil {
+		payload := MessagePayload{
+			EventID:   eventID,
+			ScoreID:   score.ID,
+			GameID:    score.GameID,
+			GroupID:   score.GroupID,
+			GameScore: &gameScore,
+			Score:     liveScore(score),
+		}
+		hub.sendStandings(MessageTypeScoreUpdate, payload, true)
+	}
+}
+
+func BroadcastScoreDelete(eventID uint, score models.Score, gameScore float64) {
+	if hub != nil {
+		payload := MessagePayload{
+			EventID:   eventID,
+			ScoreID:   score.ID,
+			GameID:    score.GameID,
+			GroupID:   score.GroupID,
+			GameScore: &gameScore,
+		}
+		hub.sendStandings(MessageTypeScoreDelete, payload, true)
+	}
+}
+
+// BroadcastEventChange sends an updated or deleted event to its displays.
+func BroadcastEventChange(messageType MessageType, event models.Event) {
+	if hub == nil {
+		return
+	}
+	payload := MessagePayload{EventID: event.ID, Entity: event}
+	if messageType == MessageTypeEventDelete {
+		hub.broadcast <- Message{Type: messageType, Data: payload}
+		return
+	}
+	hub.sendStandings(messageType, payload, false)
+}
+
+func BroadcastGroupChange(messageType MessageType, group models.Group) {
+	if hub != nil {
+		group.Event = models.Event{}
+		payload := MessagePayload{EventID: group.EventID, GroupID: group.ID, Entity: group}
+		hub.sendStandings(messageType, payload, false)
+	}
+}
+
+func BroadcastParticipantChange(messageType MessageType, eventID uint, participant models.Participant) {
+	if hub != nil {
+		participant.Group = models.Group{}
+		payload := MessagePayload{
+			EventID:       eventID,
+			GroupID:       participant.GroupID,
+			ParticipantID: participant.ID,
+			Entity:        participant,
+		}
+		hub.sendStandings(messageType, payload, false)
+	}
+}
+
+func BroadcastGameChange(messageType MessageType, game models.Game) {
+	if hub != nil {
+		game.Event = models.Event{}
+		payload := MessagePayload{EventID: game.EventID, GameID: game.ID, Entity: game}
+		hub.sendStandings(messageType, payload, false)
+	}
+}
+
+// sendStandings attaches the recomputed standings to a message: the rank
+// movement of every group, plus either the entries that changed since the
+// last message or, when there is nothing to compare against, the whole
+// leaderboard. While the event is frozen, score changes only reach admins
+// and other changes go out to everyone without standings. The lock keeps
+// the comparison in step with the send order.
+func (h *Hub) sendStandings(messageType MessageType, payload MessagePayload, scoreChange bool) {
+	var event models.Event
+	if database.DB.First(&event, payload.EventID).Error != nil {
+		return
+	}
+	if event.FrozenAt != nil && !scoreChange {
+		h.broadcast <- Message{Type: messageType, Data: payload}
+		return
+	}
+	leaderboard := scoring.EventLeaderboard(event, nil)
+	payload.Ranks = scoring.RankChanges(leaderboard)
+
+	h.boardsMux.Lock()
+	defer h.boardsMux.Unlock()
+
+	if changed, ok := changedEntries(h.boards[event.ID], leaderboard); ok {
+		payload.Changed = changed
+	} else {
+		payload.Leaderboard = leaderboard
+	}
+	h.boards[event.ID] = leaderboard
+
+	h.broadcast <- Message{
+		Type:      messageType,
+		Data:      payload,
+		adminOnly: event.FrozenAt != nil,
+	}
+}
+
+func BroadcastFreeze(eventID uint, frozenAt time.Time) {
+	if hub != nil {
+		hub.broadcast <- Message{
+			Type: MessageTypeLeaderboardFreeze,
+			Data: MessagePayload{
+				EventID:  eventID,
+				FrozenAt: &frozenAt,
+			},
+		}
+	}
+}
+
+// BroadcastReveal sends the unfrozen standings to every viewer at once.
+func BroadcastReveal(eventID uint, leaderboard any) {
+	if hub != nil {
+		hub.broadcast <- Message{
+			Type: MessageTypeLeaderboardReveal,
+			Data: MessagePayload{
+				EventID:     eventID,
+				Leaderboard: leaderboard,
+			},
+		}
+	}
+}
+
+// BroadcastRevealStart tells displays a staged reveal of the given number of
+// positions is beginning.
+func BroadcastRevealStart(eventID uint, positions int) {
 	if hub != nil {
 		hub.broadcast <- Message{
-			Type: MessageTypeScoreUpdate,
+			Type: MessageTypeRevealStart,
 			Data: MessagePayload{
-				EventID: eventID,
-				ScoreID: score.ID,
+				EventID:   eventID,
+				Remaining: &positions,
 			},
 		}
 	}
 }
 
-func BroadcastScoreDelete(eventID uint, scoreID uint) {
+// BroadcastRevealNext pushes the next position of a staged reveal.
+func BroadcastRevealNext(eventID uint, entry any, remaining int) {
 	if hub != nil {
 		hub.broadcast <- Message{
-			Type: MessageTypeScoreDelete,
+			Type: MessageTypeRevealNext,
 			Data: MessagePayload{
-				EventID: eventID,
-				ScoreID: scoreID,
+				EventID:   eventID,
+				Entry:     entry,
+				Remaining: &remaining,
 			},
 		}
 	}
 }
 
+// changedEntries returns the entries of the current leaderboard that differ
+// from the previous one. It fails when the two do not cover the same groups.
+func changedEntries(previous, current []scoring.LeaderboardEntry) ([]scoring.LeaderboardEntry, bool) {
+	if previous == nil || len(previous) != len(current) {
+		return nil, false
+	}
+	before := make(map[uint]scoring.LeaderboardEntry, len(previous))
+	for _, e := range previous {
+		before[e.GroupID] = e
+	}
+
+	var changed []scoring.LeaderboardEntry
+	for _, e := range current {
+		old, ok := before[e.GroupID]
+		if !ok {
+			return nil, false
+		}
+		if !reflect.DeepEqual(old, e) {
+			changed = append(changed, e)
+		}
+	}
+	return changed, true
+}
+
+// liveScore loads a score the way the score list shows it, marked with
+// whether it counts. Judges' individual marks are kept out of the feed.
+func liveScore(score models.Score) *models.Score {
+	var game models.Game
+	if database.DB.First(&game, score.GameID).Error != nil {
+		return nil
+	}
+	if game.ScoringMode == scoring.ModeJudged && (score.Category == "" || score.Category == scoring.CategoryBase) {
+		return nil
+	}
+
+	var scores []models.Score
+	database.DB.Where("game_id = ?", game.ID).Find(&scores)
+	scoring.MarkCounted([]models.Game{game}, scores)
+
+	var live models.Score
+	if database.DB.Preload("Group").Preload("Participant").Preload("Game").First(&live, score.ID).Error != nil {
+		return nil
+	}
+	for _, s := range scores {
+		if s.ID == live.ID {
+			live.Counted = s.Counted
+			live.Value = s.Value
+		}
+	}
+	return &live
+}
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -158,6 +390,7 @@ func HandleWebSocket(c *gin.Context) {
 	client := &Client{
 		conn:    conn,
 		eventID: event.ID,
+		admin:   middleware.GetUserID(c) != 0,
 		send:    make(chan []byte, 256),
 	}
 
@@ -1,15 +1,17 @@
 import type { Component } from 'solid-js';
-import { createSignal, onMount, createEffect, Show, For } from 'solid-js';
+import { createSignal, onMount, createEffect, on, untrack, Show, For } from 'solid-js';
 import { useParams, A } from '@solidjs/router';
 import { api } from '../../lib/api';
 import { useWebSocket } from '../../hooks/useWebSocket';
+import type { WebSocketMessage } from '../../hooks/useWebSocket';
 
 interface LeaderboardEntry {
   group_id: number;
   group_name: string;
   group_color: string;
   total_score: number;
-  rank?: number;
+  adjusted_total: number;
+  rank: number;
 }
 
 interface Event {
@@ -18,6 +20,7 @@ interface Event {
   slug: string;
   description?: string;
   status: string;
+  revealing?: boolean;
 }
 
 const Leaderboard: Component = () => {
@@ -27,6 +30,8 @@ const Leaderboard: Component = () => {
   const [leaderboard, setLeaderboard] = createSignal<LeaderboardEntry[]>([]);
   const [loading, setLoading] = createSignal(true);
   const [error, setError] = createSignal('');
+  const [revealing, setRevealing] = createSignal(false);
+  const [remaining, setRemaining] = createSignal<number | null>(null);
 
   const ws = useWebSocket(slug() || '');
 
@@ -35,6 +40,7 @@ const Leaderboard: Component = () => {
     try {
       const data = await api.events.getBySlug(slug()!);
       setEvent(data as Event);
+      setRevealing(!!(data as Event).revealing);
     } catch (err) {
       const message = err instanceof Error ? err.message : 'Failed to load event';
       setError(message);
@@ -44,13 +50,10 @@ const Leaderboard: Component = () => {
   const fetchLeaderboard = async () => {
     if (!slug()) return;
     try {
+      // The server orders the groups and assigns ranks, including shared
+      // ranks after tiebreakers, so the list is shown exactly as received.
       const data = await api.events.leaderboard(slug()!);
-      const sorted = (data as LeaderboardEntry[]).sort((a, b) => b.total_score - a.total_score);
-      const ranked = sorted.map((entry, index) => ({
-        ...entry,
-        rank: index + 1,
-      }));
-      setLeaderboard(ranked);
+      setLeaderboard(data as LeaderboardEntry[]);
     } catch (err) {
       console.error('Failed to fetch leaderboard:', err);
     } finally {
@@ -63,9 +66,74 @@ const Leaderboard: Component = () => {
     await fetchLeaderboard();
   });
 
+  // Messages that carry standings hold either the whole board or just the
+  // entries that changed, with the ranks listing every group in the
+  // server's order. Returns false when the message carries no standings.
+  const applyStandings = (data: WebSocketMessage['data']): boolean => {
+    if (data.leaderboard) {
+      setLeaderboard(data.leaderboard as LeaderboardEntry[]);
+      return true;
+    }
+    if (!data.ranks) return false;
+
+    const changed = new Map<number, LeaderboardEntry>();
+    for (const entry of (data.changed ?? []) as LeaderboardEntry[]) {
+      changed.set(entry.group_id, entry);
+    }
+    const order = new Map<number, number>();
+    data.ranks.forEach((r, index) => order.set(r.group_id, index));
+    setLeaderboard((current) =>
+      current
+        .map((entry) => changed.get(entry.group_id) ?? entry)
+        .sort((a, b) => (order.get(a.group_id) ?? a.rank) - (order.get(b.group_id) ?? b.rank))
+    );
+    return true;
+  };
+
+  // Missed messages cannot be replayed, so the board is reloaded whenever
+  // the connection comes back.
+  createEffect(
+    on(
+      ws.connected,
+      (connected, wasConnected) => {
+        if (connected && wasConnected === false && !revealing()) {
+          fetchLeaderboard();
+        }
+      },
+      { defer: true }
+    )
+  );
+
+  // A staged reveal starts from an empty board and adds one position at a
+  // time from last place up, so the newest entry always goes on top. Other
+  // changes are held back until it finishes.
   createEffect(() => {
-    if (ws.lastMessage()) {
-      fetchLeaderboard();
+    const message = ws.lastMessage();
+    if (!message) return;
+
+    switch (message.type) {
+      case 'reveal_start':
+        setRevealing(true);
+        setRemaining(message.data.remaining ?? null);
+        setLeaderboard([]);
+        break;
+      case 'reveal_next':
+        setRemaining(message.data.remaining ?? null);
+        setLeaderboard((current) => [message.data.entry as LeaderboardEntry, ...current]);
+        break;
+      case 'leaderboard_reveal':
+        setRevealing(false);
+        setRemaining(null);
+        setLeaderboard((message.data.leaderboard ?? []) as LeaderboardEntry[]);
+        break;
+      case 'leaderboard_freeze':
+      case 'event_delete':
+        break;
+      default:
+        if (untrack(revealing)) break;
+        if (!applyStandings(message.data)) {
+          fetchLeaderboard();
+        }
     }
   });
 
@@ -123,7 +191,16 @@ const Leaderboard: Component = () => {
             <p class="text-muted mb-lg">{event()?.description}</p>
           </Show>
 
-          <Show when={leaderboard().length === 0}>
+          <Show when={revealing()}>
+            <p class="text-muted mb-lg">
+              Results are being revealed
+              <Show when={remaining() !== null}>
+                {' '}&middot; {remaining()} to go
+              </Show>
+            </p>
+          </Show>
+
+          <Show when={leaderboard().length === 0 && !revealing()}>
             <div class="empty-state">
               <h3>No Scores Yet</h3>
               <p>Scores will appear here once games are scored.</p>
@@ -134,10 +211,10 @@ const Leaderboard: Component = () => {
             <div class="leaderboard-list">
               <For each={leaderboard()}>
                 {(entry) => (
-                  <div class={`leaderboard-item ${getRankClass(entry.rank!)}`}>
+                  <div class={`leaderboard-item ${getRankClass(entry.rank)}`}>
                     <div class="leaderboard-rank">
-                      <Show when={entry.rank! <= 3} fallback={<span class="rank-number">{entry.rank}</span>}>
-                        <span class={`medal ${getMedalClass(entry.rank!)}`}></span>
+                      <Show when={entry.rank <= 3} fallback={<span class="rank-number">{entry.rank}</span>}>
+                        <span class={`medal ${getMedalClass(entry.rank)}`}></span>
                       </Show>
                     </div>
                     <div class="leaderboard-group">
@@ -147,8 +224,8 @@ const Leaderboard: Component = () => {
                       ></span>
                       <span class="group-name">{entry.group_name}</span>
                     </div>
-                    <div class={`leaderboard-score ${getScoreClass(entry.total_score)}`}>
-                      {entry.total_score >= 0 ? '+' : ''}{entry.total_score}
+                    <div class={`leaderboard-score ${getScoreClass(entry.adjusted_total)}`}>
+                      {entry.adjusted_total >= 0 ? '+' : ''}{entry.adjusted_total}
                     </div>
                   </div>
                 )}
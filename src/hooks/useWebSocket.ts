@@ -1,11 +1,45 @@
 import { createSignal, onCleanup } from 'solid-js';
 import { api } from '../lib/api';
 
-interface WebSocketMessage {
-  type: 'score_update' | 'score_delete';
+export interface WebSocketMessage {
+  type:
+    | 'score_update'
+    | 'score_delete'
+    | 'leaderboard_freeze'
+    | 'leaderboard_reveal'
+    | 'reveal_start'
+    | 'reveal_next'
+    | 'event_update'
+    | 'event_delete'
+    | 'group_create'
+    | 'group_update'
+    | 'group_delete'
+    | 'participant_create'
+    | 'participant_update'
+    | 'participant_delete'
+    | 'game_create'
+    | 'game_update'
+    | 'game_delete';
   data: {
     event_id: number;
-    score_id: number;
+    score_id?: number;
+    game_id?: number;
+    group_id?: number;
+    participant_id?: number;
+    game_score?: number;
+    frozen_at?: string;
+    leaderboard?: unknown[];
+    entry?: unknown;
+    remaining?: number;
+    ranks?: {
+      group_id: number;
+      rank: number;
+      previous_rank: number;
+      rank_delta: number;
+    }[];
+    score?: unknown;
+    changed?: unknown[];
+    entity?: unknown;
   };
 }
 
@@ -151,7 +151,11 @@ export const api = {
 
   websocket: (slug: string): WebSocket => {
     const wsUrl = API_URL.replace('http', 'ws');
-    return new WebSocket(`${wsUrl}/api/events/${slug}/ws`);
+    // Browsers cannot set headers on WebSocket connections, so signed-in
+    // users pass their token in the query string to get the live standings.
+    const token = getToken();
+    const query = token ? `?token=${encodeURIComponent(token)}` : '';
+    return new WebSocket(`${wsUrl}/api/events/${slug}/ws${query}`);
   },
 };
 
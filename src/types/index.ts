@@ -14,6 +14,13 @@ export interface Event {
   description?: string;
   status: 'draft' | 'active' | 'completed';
   created_by: string;
+  tiebreakers?: ('most_wins' | 'head_to_head' | 'best_in_game' | 'earliest')[];
+  tiebreak_game_id?: number | null;
+  category_labels?: Partial<Record<ScoreCategory, string>>;
+  normalize_size?: boolean;
+  frozen_at?: string | null;
+  revealing?: boolean;
+  reveal_step?: number;
   created: string;
   updated: string;
 }
@@ -23,6 +30,8 @@ export interface Group {
   event_id: string;
   name: string;
   color?: string;
+  handicap?: number;
+  division?: string;
   sort_order: number;
   created: string;
   updated: string;
@@ -40,7 +49,28 @@ export interface Game {
   event_id: string;
   name: string;
   description?: string;
-  scoring_mode: 'incremental' | 'absolute';
+  scoring_mode: 'incremental' | 'absolute' | 'placement' | 'judged' | 'match';
+  points_table?: number[];
+  tie_mode?: 'average' | 'equal';
+  weight?: number;
+  metric_type?: 'points' | 'time' | 'distance';
+  sort_direction?: 'desc' | 'asc';
+  conversion?: '' | 'placement' | 'relative';
+  max_points?: number;
+  decimals?: number;
+  formula?: string;
+  formula_inputs?: string[];
+  min_value?: number | null;
+  max_value?: number | null;
+  max_total?: number | null;
+  disallow_negative?: boolean;
+  aggregation?: 'mean' | 'median' | 'trimmed_mean';
+  judge_ids?: number[];
+  win_points?: number;
+  draw_points?: number;
+  loss_points?: number;
+  heat_counting?: '' | 'best' | 'sum' | 'final';
+  final_heat_id?: number | null;
   status: 'pending' | 'active' | 'completed';
   sort_order: number;
   created: string;
@@ -51,13 +81,99 @@ export interface Score {
   id: string;
   game_id: string;
   group_id: string;
+  participant_id?: number | null;
   value: number;
+  inputs?: Record<string, number>;
+  placement?: number;
+  match_id?: number | null;
+  heat_id?: number | null;
+  category?: ScoreCategory;
   note?: string;
+  current?: boolean;
+  counted?: boolean;
+  override?: boolean;
   created_by: string;
   created: string;
   updated: string;
 }
 
+export interface Heat {
+  id: number;
+  game_id: number;
+  name: string;
+  group_ids: number[];
+  status: 'pending' | 'active' | 'completed';
+  sort_order: number;
+}
+
+export interface MatchEntry {
+  id: number;
+  match_id: number;
+  group_id: number;
+  group?: Group;
+  seed?: number;
+  score: number | null;
+  outcome: '' | 'win' | 'draw' | 'loss';
+}
+
+export interface Match {
+  id: number;
+  game_id: number;
+  bracket_id?: number | null;
+  schedule_id?: number | null;
+  stage?: '' | 'winners' | 'losers' | 'grand_final';
+  round: number;
+  position?: number;
+  label?: string;
+  bye?: boolean;
+  status: 'pending' | 'completed';
+  winner_id: number | null;
+  winner_next_id?: number | null;
+  loser_next_id?: number | null;
+  entries?: MatchEntry[];
+}
+
+export interface MatchRound {
+  round: number;
+  matches: Match[];
+}
+
+export interface Bracket {
+  id: number;
+  game_id: number;
+  type: 'single' | 'double';
+  seeding: 'leaderboard' | 'manual';
+  status: 'active' | 'completed';
+  champion_id: number | null;
+  winners: MatchRound[];
+  losers?: MatchRound[];
+  grand_final?: Match;
+  grand_final_reset?: Match;
+}
+
+export interface Schedule {
+  id: number;
+  game_id: number;
+  format: 'round_robin' | 'swiss';
+  total_rounds: number;
+  group_ids: number[];
+  status: 'active' | 'completed';
+  rounds: MatchRound[];
+}
+
+export interface TimelinePoint {
+  at: string;
+  total: number;
+  rank: number;
+}
+
+export interface GroupTimeline {
+  group_id: number;
+  group_name: string;
+  group_color: string;
+  points: TimelinePoint[];
+}
+
 export interface EventWithGroups extends Event {
   expand?: {
     groups?: Group[];
@@ -80,4 +196,5 @@ export interface ScoreWithDetails extends Score {
 
 export type EventStatus = Event['status'];
 export type GameStatus = Game['status'];
-export type ScoringMode = Game['scoring_mode'];
\ No newline at end of file
+export type ScoringMode = Game['scoring_mode'];
+export type ScoreCategory = 'base' | 'bonus' | 'penalty' | 'adjustment';
\ No newline at end of file